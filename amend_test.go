@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsNoEditAmend(t *testing.T) {
+	tests := []struct {
+		name       string
+		commitType string
+		editor     string
+		skip       bool
+		want       bool
+	}{
+		{"--amend --no-edit", "commit", ":", false, true},
+		{"--amend with commitment skipping the editor", "commit", "", true, true},
+		{"--amend", "commit", "vim", false, false},
+		{"-c", "commit", "vim", false, false},
+		{"-m", "message", ":", false, false},
+		{"plain commit", "", ":", false, false},
+		{"template", "template", ":", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			initRepo(t)
+			if tt.skip {
+				if err := configureSkipEditor(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.editor != "" {
+				t.Setenv("GIT_EDITOR", tt.editor)
+			}
+
+			if got := isNoEditAmend(tt.commitType); got != tt.want {
+				t.Errorf("isNoEditAmend(%q) = %v, want %v", tt.commitType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoEditAmendKeepsMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		args    []string
+	}{
+		{"default", "feat: add main\n\n", nil},
+		{"with annotate", "feat: add main\n\n", []string{"--annotate"}},
+		{"with force-editor", "feat: add main\n\n", []string{"--force-editor"}},
+		{"with a revert draft", "Revert\n\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			dir := initRepo(t)
+			commitFile(t, "main.go", "package main\n", "feat: add main")
+			writeFile(t, "main.go", "package main\n\nfunc main() {}\n")
+			runGit(t, "add", "main.go")
+
+			message := tt.message + gitComments
+			path := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+			writeFile(t, path, message)
+			// An editor that would give away it was opened
+			script := filepath.Join(dir, "edit.sh")
+			writeFile(t, script, "#!/bin/sh\necho edited > \"$1\"\n")
+			if err := os.Chmod(script, 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("VISUAL", shellQuote(script))
+			t.Setenv("GIT_EDITOR", ":")
+			t.Setenv(genericKeyEnv, "key")
+			requests := fakeOpenAI(t, &Config{})
+
+			var err error
+			stderr := captureStderr(t, func() {
+				args := append([]string{"--provider", "fake"}, tt.args...)
+				err = runRoot(t, append(args, path, "commit", "HEAD")...)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(*requests) != 0 {
+				t.Errorf("sent %d requests, want none", len(*requests))
+			}
+			if content, _ := os.ReadFile(path); string(content) != message {
+				t.Errorf("commit message file = %q, want %q", content, message)
+			}
+			if !strings.Contains(stderr, "Amending without the editor, keeping the commit message") {
+				t.Errorf("stderr = %q", stderr)
+			}
+		})
+	}
+}
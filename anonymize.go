@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+)
+
+// pathPseudonym stands in for a path in what's sent to the model. It's the
+// same for the same path on every run and keeps the extension, so the kind
+// of file still shows.
+func pathPseudonym(filePath string) string {
+	sum := sha256.Sum256([]byte(filePath))
+	return "file-" + hex.EncodeToString(sum[:4]) + path.Ext(filePath)
+}
+
+// anonymizeFiles replaces the paths of `git diff --name-status` lines with
+// their pseudonyms.
+func anonymizeFiles(files string) string {
+	lines := strings.Split(files, "\n")
+	for i, line := range lines {
+		fields := strings.Split(line, "\t")
+		for j := 1; j < len(fields); j++ {
+			fields[j] = pathPseudonym(fields[j])
+		}
+		lines[i] = strings.Join(fields, "\t")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// anonymizeDiff replaces the paths in the file headers of the diff with
+// their pseudonyms. Paths are only looked for in the headers, the changed
+// lines are left as they are.
+func anonymizeDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	header := false
+	for i, line := range lines {
+		if paths, ok := strings.CutPrefix(line, "diff --git a/"); ok {
+			header = true
+			if from, to, found := strings.Cut(paths, " b/"); found {
+				lines[i] = "diff --git a/" + pathPseudonym(from) + " b/" + pathPseudonym(to)
+			}
+			continue
+		}
+		// Past the header a line like "--- x" is a removed line
+		if strings.HasPrefix(line, "@@") {
+			header = false
+		}
+		if !header {
+			continue
+		}
+
+		if names, ok := strings.CutPrefix(line, "Binary files "); ok {
+			if from, to, found := strings.Cut(strings.TrimSuffix(names, " differ"), " and "); found {
+				lines[i] = "Binary files " + anonymizeDiffPath(from) + " and " + anonymizeDiffPath(to) + " differ"
+			}
+			continue
+		}
+
+		for _, prefix := range []string{"--- ", "+++ ", "rename from ", "rename to ", "copy from ", "copy to "} {
+			if filePath, ok := strings.CutPrefix(line, prefix); ok {
+				lines[i] = prefix + anonymizeDiffPath(filePath)
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// anonymizeDiffPath replaces a path as the diff shows it, after its a/ or b/
+// prefix. /dev/null stays.
+func anonymizeDiffPath(filePath string) string {
+	for _, prefix := range []string{"a/", "b/"} {
+		if rest, ok := strings.CutPrefix(filePath, prefix); ok {
+			return prefix + pathPseudonym(rest)
+		}
+	}
+	if filePath == "/dev/null" {
+		return filePath
+	}
+
+	return pathPseudonym(filePath)
+}
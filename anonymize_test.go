@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const submoduleDiff = `diff --git a/libs/secretproj b/libs/secretproj
+index 1111111..2222222 160000
+--- a/libs/secretproj
++++ b/libs/secretproj
+@@ -1 +1 @@
+-Subproject commit 1111111111111111111111111111111111111111
++Subproject commit 2222222222222222222222222222222222222222
+`
+
+func TestAnonymizeDiff(t *testing.T) {
+	diff := `diff --git a/internal/billing/invoice.go b/internal/billing/invoice.go
+index 3b18e51..a1b2c3d 100644
+--- a/internal/billing/invoice.go
++++ b/internal/billing/invoice.go
+@@ -1,2 +1,2 @@
+--- a/not/a/header
++++ b/not/a/header
+diff --git a/old.txt b/new.txt
+rename from old.txt
+rename to new.txt
+diff --git a/logo.png b/logo.png
+Binary files /dev/null and b/logo.png differ
+`
+	got := anonymizeDiff(diff)
+
+	for _, path := range []string{"internal/billing", "old.txt", "new.txt", "logo.png"} {
+		if strings.Contains(got, path) {
+			t.Errorf("anonymized diff still names %s:\n%s", path, got)
+		}
+	}
+	// Changed lines are content, even when they look like headers
+	if !strings.Contains(got, "--- a/not/a/header\n+++ b/not/a/header") {
+		t.Errorf("changed lines were rewritten:\n%s", got)
+	}
+	if !strings.Contains(got, "diff --git a/"+pathPseudonym("internal/billing/invoice.go")+" ") {
+		t.Errorf("header doesn't use the pseudonym:\n%s", got)
+	}
+	if !strings.Contains(got, "Binary files /dev/null and b/"+pathPseudonym("logo.png")+" differ") {
+		t.Errorf("binary line not anonymized:\n%s", got)
+	}
+}
+
+func TestPathPseudonym(t *testing.T) {
+	if pathPseudonym("a/b.go") != pathPseudonym("a/b.go") {
+		t.Error("pseudonyms differ for the same path")
+	}
+	if pathPseudonym("a/b.go") == pathPseudonym("a/c.go") {
+		t.Error("pseudonyms are the same for different paths")
+	}
+	if !strings.HasSuffix(pathPseudonym("a/b.go"), ".go") {
+		t.Errorf("pseudonym %s lost the extension", pathPseudonym("a/b.go"))
+	}
+	if got := anonymizeFiles("M\ta.go\nR100\told.go\tnew.go"); strings.Contains(got, "a.go") || strings.Count(got, "file-") != 3 {
+		t.Errorf("anonymizeFiles() = %q", got)
+	}
+}
+
+func TestBuildUserPromptAnonymizesSubmodules(t *testing.T) {
+	isolate(t)
+	cfg := &Config{AnonymizePaths: true, SubmoduleLog: true, StripDiffMetadata: true}
+
+	prompt := buildUserPrompt(cfg, submoduleDiff, "M\tlibs/secretproj")
+	if strings.Contains(prompt, "secretproj") {
+		t.Errorf("prompt names the submodule:\n%s", prompt)
+	}
+	want := "bumped submodule " + pathPseudonym("libs/secretproj") + " from 1111111 to 2222222"
+	if !strings.Contains(prompt, want) {
+		t.Errorf("prompt lacks %q:\n%s", want, prompt)
+	}
+}
+
+func TestDescribeSubmodules(t *testing.T) {
+	got := describeSubmodules(submoduleDiff, false, false)
+	if !strings.Contains(got, "bumped submodule libs/secretproj from 1111111 to 2222222") || strings.Contains(got, "Subproject commit") {
+		t.Errorf("describeSubmodules() = %q", got)
+	}
+}
+
+func TestPreviewAnonymizesAutoScope(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	writeFile(t, "internal/auth/login.go", "package main\n")
+	writeFile(t, "internal/auth/token.go", "package main\n")
+	runGit(t, "add", ".")
+
+	cfg, err := loadTestConfig(t, "--anonymize-paths", "--auto-scope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "prompt")
+	if err := previewPrompt(cfg, output); err != nil {
+		t.Fatal(err)
+	}
+	preview, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"auth", "internal", "login", "token"} {
+		if strings.Contains(string(preview), name) {
+			t.Errorf("preview names %q:\n%s", name, preview)
+		}
+	}
+
+	// Without the pseudonyms the directory is the scope
+	cfg.AnonymizePaths = false
+	if _, _, scope := messagePrompt(cfg, "", "A\tinternal/auth/login.go\nA\tinternal/auth/token.go", false); scope != "auth" {
+		t.Errorf("scope = %q, want auth", scope)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// providerKeyEnv names the environment variable holding the key of each
+// provider, both currently talk to Gemini. The presets name their own.
+var providerKeyEnv = map[string]string{
+	providerOpenAI:       "GEMINI_API_KEY",
+	providerGeminiNative: "GEMINI_API_KEY",
+}
+
+// genericKeyEnv holds the key for whichever provider is configured.
+const genericKeyEnv = "COMMITMENT_API_KEY"
+
+// resolveAPIKey finds the API key and where it came from, trying the
+// provider's environment variable, the generic one, the config and finally
+// the key file. Both are empty when there is no key.
+func resolveAPIKey(cfg *Config) (string, string) {
+	for _, name := range []string{cfg.keyEnv(), genericKeyEnv} {
+		if key := os.Getenv(name); key != "" {
+			return key, "env " + name
+		}
+	}
+
+	if cfg.APIKey != "" {
+		return cfg.APIKey, cfg.Sources["api_key"]
+	}
+
+	path := apiKeyFile(cfg)
+	if path == "" {
+		return "", ""
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+	if key := strings.TrimSpace(string(content)); key != "" {
+		return key, path
+	}
+
+	return "", ""
+}
+
+// apiKeyFile is the configured key file, or commitment/api_key in the user
+// config directory.
+func apiKeyFile(cfg *Config) string {
+	if cfg.APIKeyFile != "" {
+		return expandHome(cfg.APIKeyFile)
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "commitment", "api_key")
+}
+
+// missingAPIKeyError explains where the key can be set.
+func missingAPIKeyError(cfg *Config) error {
+	return fmt.Errorf("Error: No API key set, export %s or %s", cfg.keyEnv(), genericKeyEnv)
+}
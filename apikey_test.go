@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		config     string
+		keyFile    string
+		want       string
+		wantSource string
+	}{
+		{"none", nil, "", "", "", ""},
+		{"provider env", map[string]string{"GEMINI_API_KEY": "provider", genericKeyEnv: "generic"}, `{"api_key": "config"}`, "file", "provider", "env GEMINI_API_KEY"},
+		{"generic env", map[string]string{genericKeyEnv: "generic"}, `{"api_key": "config"}`, "file", "generic", "env " + genericKeyEnv},
+		{"config", nil, `{"api_key": "config"}`, "file", "config", "config"},
+		{"key file", nil, "", " file\n", "file", "file"},
+		{"empty key file", nil, "", "\n", "", ""},
+		{"custom key file", nil, `{"api_key_file": "~/secrets/key"}`, "custom", "custom", "file"},
+		{"preset env", map[string]string{"GROQ_API_KEY": "groq", "GEMINI_API_KEY": "gemini"}, `{"provider": "groq"}`, "", "groq", "env GROQ_API_KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := isolate(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if tt.config != "" {
+				writeFile(t, path, tt.config)
+			}
+			cfg, err := loadTestConfig(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyFile := apiKeyFile(cfg)
+			if tt.keyFile != "" {
+				writeFile(t, keyFile, tt.keyFile)
+			}
+
+			key, source := resolveAPIKey(cfg)
+			if key != tt.want {
+				t.Errorf("key = %q, want %q", key, tt.want)
+			}
+			switch tt.wantSource {
+			case "config":
+				if source != path {
+					t.Errorf("source = %q, want the config %q", source, path)
+				}
+			case "file":
+				if source != keyFile {
+					t.Errorf("source = %q, want the key file %q", source, keyFile)
+				}
+			default:
+				if source != tt.wantSource {
+					t.Errorf("source = %q, want %q", source, tt.wantSource)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIKeyFile(t *testing.T) {
+	isolate(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if path, want := apiKeyFile(&Config{}), filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "commitment", "api_key"); path != want {
+		t.Errorf("default key file = %q, want %q", path, want)
+	}
+	if path := apiKeyFile(&Config{APIKeyFile: "~/key"}); path != filepath.Join(home, "key") {
+		t.Errorf("key file = %q, want it under the home directory", path)
+	}
+	if path := apiKeyFile(&Config{APIKeyFile: "/etc/key"}); path != "/etc/key" {
+		t.Errorf("key file = %q, want it as configured", path)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// asciiInstruction asks the model to stay within ASCII, toASCII cleans up
+// whatever slips through.
+const asciiInstruction = "Use only ASCII characters in the commit message: no emoji, accented letters, typographic quotes or dashes."
+
+// asciiReplacements transliterates the non-ASCII characters that commonly
+// show up in generated messages, anything else is dropped.
+var asciiReplacements = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '′': "'",
+	'“': `"`, '”': `"`, '„': `"`, '″': `"`, '«': `"`, '»': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...", '→': "->", '←': "<-", '⇒': "=>", '×': "x", '•': "*", '·': "*",
+	'\u00a0': " ", '\u2009': " ", '\u202f': " ",
+
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ą': "A", 'Ā': "A", 'Ă': "A",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ą': "a", 'ā': "a", 'ă': "a",
+	'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'ß': "ss", 'Þ': "Th", 'þ': "th",
+	'Ç': "C", 'Ć': "C", 'Č': "C", 'ç': "c", 'ć': "c", 'č': "c",
+	'Ď': "D", 'Đ': "D", 'Ð': "D", 'ď': "d", 'đ': "d", 'ð': "d",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ę': "E", 'Ě': "E", 'Ē': "E",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ę': "e", 'ě': "e", 'ē': "e",
+	'Ğ': "G", 'ğ': "g",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'İ': "I", 'Ī': "I",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ı': "i", 'ī': "i",
+	'Ł': "L", 'Ľ': "L", 'ł': "l", 'ľ': "l",
+	'Ñ': "N", 'Ń': "N", 'Ň': "N", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ő': "O", 'Ō': "O",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ő': "o", 'ō': "o",
+	'Ř': "R", 'ř': "r",
+	'Ś': "S", 'Š': "S", 'Ş': "S", 'ś': "s", 'š': "s", 'ş': "s",
+	'Ť': "T", 'Ţ': "T", 'ť': "t", 'ţ': "t",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ů': "U", 'Ű': "U", 'Ū': "U",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ů': "u", 'ű': "u", 'ū': "u",
+	'Ý': "Y", 'Ÿ': "Y", 'ý': "y", 'ÿ': "y",
+	'Ź': "Z", 'Ż': "Z", 'Ž': "Z", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// toASCII transliterates common non-ASCII characters and drops the rest,
+// along with the space left behind by a dropped emoji.
+func toASCII(message string) string {
+	var result strings.Builder
+	var last rune = '\n'
+	dropped := false
+	for _, r := range message {
+		if replacement, ok := asciiReplacements[r]; ok {
+			result.WriteString(replacement)
+			last, dropped = rune(replacement[len(replacement)-1]), false
+			continue
+		}
+
+		if r > unicode.MaxASCII {
+			dropped = true
+			continue
+		}
+
+		if dropped && r == ' ' && (last == ' ' || last == '\n') {
+			continue
+		}
+		// Nor the space before it at the end of a line
+		if dropped && r == '\n' && last == ' ' {
+			trimmed := strings.TrimRight(result.String(), " ")
+			result.Reset()
+			result.WriteString(trimmed)
+		}
+		result.WriteRune(r)
+		last, dropped = r, false
+	}
+
+	return strings.TrimRight(result.String(), " ")
+}
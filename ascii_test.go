@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"fix: handle nil", "fix: handle nil"},
+		{"feat: add café menu to Zürich’s façade", "feat: add cafe menu to Zurich's facade"},
+		{"fix: zażółć gęślą jaźń", "fix: zazolc gesla jazn"},
+		{"docs: “quote” — dash… and → arrow", `docs: "quote" - dash... and -> arrow`},
+		{"feat: ✨ add sparkles 🎉", "feat: add sparkles"},
+		{"🐛 fix: crash\n\n- ✅ tested", "fix: crash\n\n- tested"},
+		{"fix: 日本語 support", "fix: support"},
+		{"feat: ship it 🚀\nSee below 🎉\n", "feat: ship it\nSee below\n"},
+		{"keep  spaces  \nhere", "keep  spaces  \nhere"},
+		{"Straße Œuvre", "Strasse OEuvre"},
+	}
+	for _, tt := range tests {
+		got := toASCII(tt.message)
+		if got != tt.want {
+			t.Errorf("toASCII(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+		if strings.IndexFunc(got, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+			t.Errorf("toASCII(%q) left non-ASCII characters: %q", tt.message, got)
+		}
+	}
+}
+
+func TestFinishMessageASCII(t *testing.T) {
+	cfg := &Config{ASCIIOnly: true, PostProcessors: defaultPostProcessors}
+	got := finishMessage(cfg, "feat: résumé upload ✨\n\nCandidates can attach their résumé — PDF only.", nil, "", false, "m")
+	if want := "feat: resume upload\n\nCandidates can attach their resume - PDF only."; got != want {
+		t.Errorf("finishMessage() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+var backfillCmd = &cli.Command{
+	Name:      "backfill",
+	Usage:     "Suggest a message for each commit in a range, as JSON mapping commits to messages",
+	ArgsUsage: "range",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "write the JSON to `FILE`, - for stdout",
+			Value: "-",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Args().Len() < 1 {
+			return fmt.Errorf("Error: No commit range provided, e.g. main..HEAD")
+		}
+
+		if err := requireGit(); err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if cfg.Report {
+			defer report.print()
+		}
+
+		apiKey, source := resolveAPIKey(cfg)
+		if apiKey == "" {
+			return missingAPIKeyError(cfg)
+		}
+		if cfg.ValidateKey && !keyWorks(cfg, apiKey, source) {
+			return fmt.Errorf("Error: Invalid API key")
+		}
+
+		commits, err := rangeCommits(cmd.Args().First())
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			return fmt.Errorf("Error: No commits in range %s", cmd.Args().First())
+		}
+
+		messages := map[string]string{}
+		for i, commit := range commits {
+			short := commit[:12]
+			fmt.Fprintf(os.Stderr, "🤖 Commit %s (%d/%d)\n", short, i+1, len(commits))
+
+			diff, err := prepareDiff(cfg, commitDiff(cfg, commit), apiKey)
+			if err != nil || diff == "" {
+				fmt.Fprintf(os.Stderr, "⚠️ Skipping %s\n", short)
+				continue
+			}
+
+			cfg.HistoryBase = commit
+			message := generateCommitMessage(cfg, diff, commitFiles(commit), apiKey)
+			if message == "" {
+				fmt.Fprintf(os.Stderr, "⚠️ No message generated for %s\n", short)
+				continue
+			}
+			messages[commit] = message
+		}
+
+		encoded, err := json.MarshalIndent(messages, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Failed to encode commit messages: %w", err)
+		}
+
+		if err := writeOutput(cmd.String("output"), string(encoded)); err != nil {
+			return fmt.Errorf("Failed to write commit messages: %w", err)
+		}
+
+		return nil
+	},
+}
+
+// rangeCommits lists the full hashes of the non-merge commits in a revision
+// range, oldest first.
+func rangeCommits(revisionRange string) ([]string, error) {
+	output, err := exec.Command("git", "rev-list", "--reverse", "--no-merges", revisionRange).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error: Invalid commit range %s", revisionRange)
+	}
+
+	return strings.Fields(string(output)), nil
+}
+
+// commitDiff is the patch a commit introduces, against the empty tree for the
+// root commit.
+func commitDiff(cfg *Config, commit string) string {
+	args := append([]string{"diff-tree", "-p", "--root", "--no-commit-id"}, diffOptions(cfg)...)
+	output, err := exec.Command("git", append(args, commit)...).Output()
+	if err != nil {
+		return ""
+	}
+
+	return string(output)
+}
+
+// commitFiles lists the files a commit changes, like getChangedFiles does for
+// the staged changes.
+func commitFiles(commit string) string {
+	output, err := exec.Command("git", "diff-tree", "-r", "--root", "--no-commit-id", "--name-status", commit).Output()
+	if err != nil {
+		return ""
+	}
+
+	return string(output)
+}
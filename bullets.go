@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bulletInstruction asks for the body as a bullet list, optionally capped.
+func bulletInstruction(maxBullets int) string {
+	instruction := "Write the body as a bullet list of the notable changes, each explaining why."
+	if maxBullets > 0 {
+		instruction += fmt.Sprintf(" Use at most %d bullet points.", maxBullets)
+	}
+
+	return instruction
+}
+
+// limitBullets drops the top-level bullet points after the first max ones,
+// along with their indented continuation lines. Anything after the list,
+// like trailers, is kept.
+func limitBullets(message string, max int) string {
+	if max <= 0 {
+		return message
+	}
+
+	lines := strings.Split(message, "\n")
+	kept := make([]string, 0, len(lines))
+	count := 0
+	dropping := false
+	for _, line := range lines {
+		isBullet := reListItem.MatchString(line) && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t")
+		isContinuation := line != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"))
+
+		switch {
+		case isBullet:
+			count++
+			dropping = count > max
+		case isContinuation:
+			// Belongs to the previous bullet
+		default:
+			dropping = false
+		}
+
+		if !dropping {
+			kept = append(kept, line)
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
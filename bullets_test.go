@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestLimitBullets(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		max     int
+		want    string
+	}{
+		{"unlimited", "feat: x\n\n- a\n- b\n- c", 0, "feat: x\n\n- a\n- b\n- c"},
+		{"within the limit", "feat: x\n\n- a\n- b", 2, "feat: x\n\n- a\n- b"},
+		{"trimmed", "feat: x\n\n- a\n- b\n- c\n- d", 2, "feat: x\n\n- a\n- b"},
+		{"other markers", "feat: x\n\n* a\n* b\n1. c", 1, "feat: x\n\n* a"},
+		{"continuation lines", "feat: x\n\n- a\n  more on a\n- b\n  more on b", 1, "feat: x\n\n- a\n  more on a"},
+		{"nested points stay with their parent", "feat: x\n\n- a\n  - a.1\n- b", 1, "feat: x\n\n- a\n  - a.1"},
+		{"trailers kept", "feat: x\n\n- a\n- b\n\nSigned-off-by: A <a@example.com>", 1, "feat: x\n\n- a\n\nSigned-off-by: A <a@example.com>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limitBullets(tt.message, tt.max); got != tt.want {
+				t.Errorf("limitBullets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulletInstruction(t *testing.T) {
+	if got := bulletInstruction(0); got != "Write the body as a bullet list of the notable changes, each explaining why." {
+		t.Errorf("bulletInstruction(0) = %q", got)
+	}
+	if got := bulletInstruction(3); got != "Write the body as a bullet list of the notable changes, each explaining why. Use at most 3 bullet points." {
+		t.Errorf("bulletInstruction(3) = %q", got)
+	}
+}
+
+func TestFinishMessageBullets(t *testing.T) {
+	cfg := &Config{Bullets: true, MaxBullets: 2, PostProcessors: defaultPostProcessors, WrapWidth: defaultWrapWidth}
+	message := "feat: add export\n\n- add CSV\n- add JSON\n- add XML"
+	if got := finishMessage(cfg, message, nil, "", false, "m"); got != "feat: add export\n\n- add CSV\n- add JSON" {
+		t.Errorf("finishMessage() = %q", got)
+	}
+
+	cfg.Bullets = false
+	if got := finishMessage(cfg, message, nil, "", false, "m"); got != message {
+		t.Errorf("finishMessage() without bullet mode = %q", got)
+	}
+}
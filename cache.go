@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// cacheKey identifies a completion by everything that influences its result,
+// so changing the prompt, the diff, the model or any setting sent along with
+// them never serves a stale entry.
+func cacheKey(cfg *Config, completion CompletionRequest) string {
+	keyData, _ := json.Marshal(struct {
+		Provider       string
+		URL            string
+		Method         string
+		SystemRole     string
+		Temperature    float64
+		Seed           *int
+		NoReasoning    bool
+		TopP           *float64
+		TopK           *int
+		SafetySettings []GeminiSafetySetting
+		Completion     CompletionRequest
+	}{
+		Provider:       cfg.Provider,
+		URL:            cfg.baseURL() + cfg.APIPath,
+		Method:         cfg.APIMethod,
+		SystemRole:     cfg.systemRole(completion.Model),
+		Temperature:    cfg.temperature(),
+		Seed:           cfg.Seed,
+		NoReasoning:    cfg.NoReasoning,
+		TopP:           cfg.TopP,
+		TopK:           cfg.TopK,
+		SafetySettings: geminiSafetySettings(cfg),
+		Completion:     completion,
+	})
+
+	sum := sha256.Sum256(keyData)
+	return hex.EncodeToString(sum[:])
+}
+
+func cachePath(key string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "commitment", key), nil
+}
+
+// readCache returns the cached completion for the key, if there is one.
+func readCache(key string) (string, bool) {
+	path, err := cachePath(key)
+	if err != nil {
+		return "", false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	return string(content), true
+}
+
+// writeCache stores a completion, failures only mean the next run asks again.
+func writeCache(key, text string) {
+	path, err := cachePath(key)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, []byte(text), 0644)
+}
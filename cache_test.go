@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheKey(t *testing.T) {
+	seed, topK, topP := 42, 40, 0.9
+	base := func() *Config {
+		return &Config{Provider: providerOpenAI, APIPath: defaultAPIPath, APIMethod: defaultAPIMethod}
+	}
+	completion := CompletionRequest{Model: "m", System: "system", Prompt: "diff", MaxTokens: 100}
+
+	tests := []struct {
+		name   string
+		change func(cfg *Config, completion *CompletionRequest)
+	}{
+		{"provider", func(cfg *Config, _ *CompletionRequest) { cfg.Provider = providerGeminiNative }},
+		{"API path", func(cfg *Config, _ *CompletionRequest) { cfg.APIPath = "/v1/chat" }},
+		{"API method", func(cfg *Config, _ *CompletionRequest) { cfg.APIMethod = "PUT" }},
+		{"system role", func(cfg *Config, _ *CompletionRequest) { cfg.SystemRole = "developer" }},
+		{"seed", func(cfg *Config, _ *CompletionRequest) { cfg.Seed = &seed }},
+		{"no reasoning", func(cfg *Config, _ *CompletionRequest) { cfg.NoReasoning = true }},
+		{"top_p", func(cfg *Config, _ *CompletionRequest) { cfg.TopP = &topP }},
+		{"top_k", func(cfg *Config, _ *CompletionRequest) { cfg.TopK = &topK }},
+		{"safety off", func(cfg *Config, _ *CompletionRequest) { cfg.SafetyOff = true }},
+		{"safety threshold", func(cfg *Config, _ *CompletionRequest) { cfg.SafetyThreshold = "BLOCK_ONLY_HIGH" }},
+		{"safety settings", func(cfg *Config, _ *CompletionRequest) {
+			cfg.SafetySettings = map[string]string{"HARM_CATEGORY_HARASSMENT": "BLOCK_NONE"}
+		}},
+		{"model", func(_ *Config, completion *CompletionRequest) { completion.Model = "other" }},
+		{"prompt", func(_ *Config, completion *CompletionRequest) { completion.Prompt = "other diff" }},
+		{"system prompt", func(_ *Config, completion *CompletionRequest) { completion.System = "other system" }},
+		{"max tokens", func(_ *Config, completion *CompletionRequest) { completion.MaxTokens = 200 }},
+		{"structured", func(_ *Config, completion *CompletionRequest) { completion.Structured = true }},
+	}
+
+	want := cacheKey(base(), completion)
+	if again := cacheKey(base(), completion); again != want {
+		t.Fatalf("the same request got keys %s and %s", want, again)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, changed := base(), completion
+			tt.change(cfg, &changed)
+			if cacheKey(cfg, changed) == want {
+				t.Errorf("changing the %s kept the cache key", tt.name)
+			}
+		})
+	}
+}
+
+func TestCacheMissesWithAnotherSystemPrompt(t *testing.T) {
+	isolate(t)
+	dir := initRepo(t)
+	writeFile(t, "main.go", "package main\n")
+	runGit(t, "add", "main.go")
+	promptFile := filepath.Join(dir, ".git", "prompt.txt")
+	writeFile(t, promptFile, "Write a commit message.")
+
+	// One server for all runs, its address is part of the key
+	requests := fakeOpenAI(t, &Config{}, textResponse("feat: add main", "stop"), textResponse("feat: add main", "stop"))
+	generate := func() {
+		t.Helper()
+		cfg, err := loadTestConfig(t, "--provider", "fake", "--cache", "--prompt-file", promptFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff, files, err := collectChanges(cfg, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		captureStderr(t, func() { generateCommitMessage(cfg, diff, files, "key") })
+	}
+
+	generate()
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	generate()
+	if len(*requests) != 1 {
+		t.Errorf("sent %d requests for the same prompt, want it cached", len(*requests))
+	}
+	writeFile(t, promptFile, "Write a terse commit message.")
+	generate()
+	if len(*requests) != 2 {
+		t.Errorf("sent %d requests after changing the system prompt, want 2", len(*requests))
+	}
+}
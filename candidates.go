@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// generateCandidates generates the configured number of messages
+// concurrently and returns the distinct ones, in the order they were asked
+// for.
+func generateCandidates(cfg *Config, diff, files, apiKey string) []string {
+	// Every candidate needs its own response, and must not fan out again
+	single := *cfg
+	single.Candidates = 0
+	single.Cache = false
+
+	messages := make([]string, cfg.Candidates)
+	limit := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range messages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			messages[i] = generateCommitMessage(&single, diff, files, apiKey)
+		}()
+	}
+	wg.Wait()
+
+	distinct := []string{}
+	seen := map[string]bool{}
+	for _, message := range messages {
+		key := normalizeCandidate(message)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		distinct = append(distinct, message)
+	}
+
+	return distinct
+}
+
+// normalizeCandidate reduces a message to what tells it apart from another,
+// ignoring case, whitespace and trailing punctuation.
+func normalizeCandidate(message string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(message), " "))
+	return strings.TrimRight(normalized, ".!")
+}
+
+// pickCandidate lists the candidates and lets the user choose one when
+// running interactively, otherwise the first one is used.
+func pickCandidate(cfg *Config, candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	for i, candidate := range candidates {
+		fmt.Fprintf(os.Stderr, "\n%d) %s\n", i+1, strings.ReplaceAll(candidate, "\n", "\n   "))
+	}
+	fmt.Fprintln(os.Stderr)
+
+	if !cfg.Interactive {
+		return candidates[0]
+	}
+
+	return candidates[choose("Which message?", len(candidates))]
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// The optional request features a provider may or may not support.
+const (
+	featureStreaming          = "streaming"
+	featureTools              = "tools"
+	featureJSON               = "json"
+	featureSeed               = "seed"
+	featureNoReasoning        = "no_reasoning"
+	featureGenerationSettings = "generation_settings"
+)
+
+var allFeatures = []string{
+	featureStreaming, featureTools, featureJSON, featureSeed, featureNoReasoning, featureGenerationSettings,
+}
+
+// providerCapabilities declares the features each provider supports, a new
+// provider only needs an entry here. The presets declare theirs along with
+// the rest of their settings.
+var providerCapabilities = map[string][]string{
+	providerOpenAI:       {featureStreaming, featureTools, featureJSON, featureSeed, featureNoReasoning},
+	providerGeminiNative: {featureJSON, featureSeed, featureNoReasoning, featureGenerationSettings},
+}
+
+// supports reports whether the configured provider supports the feature and
+// it wasn't declared unsupported in the config, e.g. for a gateway in front
+// of the API.
+func (c *Config) supports(feature string) bool {
+	return slices.Contains(c.capabilities(), feature) && !slices.Contains(c.UnsupportedFeatures, feature)
+}
+
+// disableUnsupported turns off the settings the provider can't honor, with a
+// warning, rather than sending a request it would reject.
+func (c *Config) disableUnsupported() {
+	if c.Seed != nil && !c.supports(featureSeed) {
+		fmt.Fprintf(os.Stderr, "⚠️ %s doesn't support seeds, ignoring the seed\n", c.Provider)
+		c.Seed = nil
+	}
+	if c.NoReasoning && !c.supports(featureNoReasoning) {
+		fmt.Fprintf(os.Stderr, "⚠️ %s can't turn off reasoning, ignoring --no-reasoning\n", c.Provider)
+		c.NoReasoning = false
+	}
+	if c.Explain && !c.supports(featureJSON) {
+		fmt.Fprintf(os.Stderr, "⚠️ %s doesn't support JSON responses, ignoring --explain\n", c.Provider)
+		c.Explain = false
+	}
+	if c.hasGeminiSettings() && !c.supports(featureGenerationSettings) {
+		fmt.Fprintln(os.Stderr, "⚠️ Safety and generation settings are only honored by the gemini-native provider")
+	}
+}
+
+// adaptCompletion drops the request features the provider doesn't support.
+// They're optimizations the response works without, so it's done quietly.
+func adaptCompletion(cfg *Config, completion CompletionRequest) CompletionRequest {
+	if completion.Structured && !cfg.supports(featureTools) {
+		logVerbose(cfg, "🤖 %s doesn't support tools, asking for plain text", cfg.Provider)
+		completion.Structured = false
+	}
+	if completion.FirstLineOnly && !cfg.supports(featureStreaming) {
+		logVerbose(cfg, "🤖 %s doesn't support streaming, waiting for the whole response", cfg.Provider)
+		completion.FirstLineOnly = false
+	}
+	if completion.JSON && !cfg.supports(featureJSON) {
+		completion.JSON = false
+	}
+
+	return completion
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		feature string
+		want    bool
+	}{
+		{Config{Provider: providerOpenAI}, featureTools, true},
+		{Config{Provider: providerOpenAI}, featureGenerationSettings, false},
+		{Config{Provider: providerGeminiNative}, featureStreaming, false},
+		{Config{Provider: providerGeminiNative}, featureGenerationSettings, true},
+		{Config{Provider: "mistral"}, featureSeed, false},
+		{Config{Provider: "groq"}, featureSeed, true},
+		{Config{Provider: "groq"}, featureNoReasoning, false},
+		{Config{Provider: providerOpenAI, UnsupportedFeatures: []string{featureTools}}, featureTools, false},
+		{Config{Provider: providerOpenAI, UnsupportedFeatures: []string{featureTools}}, featureJSON, true},
+		{Config{Provider: "unknown"}, featureJSON, false},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.supports(tt.feature); got != tt.want {
+			t.Errorf("%s with %q unsupported supports(%s) = %v, want %v", tt.cfg.Provider, tt.cfg.UnsupportedFeatures, tt.feature, got, tt.want)
+		}
+	}
+}
+
+func TestDisableUnsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		check   func(cfg *Config) bool
+		warning string
+	}{
+		{"seed", "", []string{"--provider", "mistral", "--seed", "1"}, func(cfg *Config) bool { return cfg.Seed == nil }, "mistral doesn't support seeds"},
+		{"seed supported", "", []string{"--provider", "groq", "--seed", "1"}, func(cfg *Config) bool { return cfg.Seed != nil && *cfg.Seed == 1 }, ""},
+		{"no reasoning", "", []string{"--provider", "groq", "--no-reasoning"}, func(cfg *Config) bool { return !cfg.NoReasoning }, "groq can't turn off reasoning"},
+		{"explain", `{"unsupported_features": ["json"]}`, []string{"--explain"}, func(cfg *Config) bool { return !cfg.Explain }, "doesn't support JSON responses"},
+		{"safety settings", `{"safety_settings": {"HARM_CATEGORY_HARASSMENT": "BLOCK_NONE"}}`, nil, func(cfg *Config) bool { return true }, "only honored by the gemini-native provider"},
+		{"safety settings supported", `{"provider": "gemini-native", "safety_settings": {"HARM_CATEGORY_HARASSMENT": "BLOCK_NONE"}}`, nil, func(cfg *Config) bool { return true }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := isolate(t)
+			if tt.config != "" {
+				writeFile(t, path, tt.config)
+			}
+
+			var cfg *Config
+			var err error
+			stderr := captureStderr(t, func() { cfg, err = loadTestConfig(t, tt.args...) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("setting kept: %+v", cfg)
+			}
+			if tt.warning == "" && strings.Contains(stderr, "⚠️") || !strings.Contains(stderr, tt.warning) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.warning)
+			}
+		})
+	}
+}
+
+func TestRequestsOmitUnsupportedFields(t *testing.T) {
+	seed := 7
+	tests := []struct {
+		name        string
+		unsupported []string
+		response    string
+		omitted     bool
+	}{
+		{"all supported", nil, toolCallResponse(`{"type": "fix", "subject": "typo"}`, "tool_calls"), false},
+		{"none supported", allFeatures, textResponse("fix: typo", "stop"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			cfg := &Config{Seed: &seed, NoReasoning: true, UnsupportedFeatures: tt.unsupported}
+			requests := fakeOpenAI(t, cfg, tt.response)
+			captureStderr(t, cfg.disableUnsupported)
+
+			completion := CompletionRequest{Model: "m", Prompt: "diff", MaxTokens: 100, Structured: true, JSON: true, FirstLineOnly: tt.omitted}
+			if text := complete(cfg, completion, "key"); !strings.Contains(text, "typo") {
+				t.Errorf("complete() = %q", text)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(*requests))
+			}
+
+			request := (*requests)[0]
+			fields := map[string]bool{
+				"seed":             request.Seed != nil,
+				"tools":            request.Tools != nil,
+				"tool_choice":      request.ToolChoice != nil,
+				"response_format":  request.ResponseFormat != nil,
+				"reasoning_effort": request.ReasoningEffort != "",
+			}
+			if tt.omitted {
+				fields["stream"] = request.Stream
+			}
+			for field, sent := range fields {
+				if sent == tt.omitted {
+					t.Errorf("%s sent = %v", field, sent)
+				}
+			}
+		})
+	}
+}
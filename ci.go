@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultCIEnvVars are set by common CI services, any of them means the
+// hook runs in automation.
+var defaultCIEnvVars = []string{
+	"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "TRAVIS", "JENKINS_URL",
+	"TF_BUILD", "TEAMCITY_VERSION", "BITBUCKET_BUILD_NUMBER", "DRONE", "CODEBUILD_BUILD_ID",
+}
+
+// ciEnvVar returns the first of the variables that is set, and not to
+// "false" or "0", or an empty string outside CI.
+func ciEnvVar(names []string) string {
+	for _, name := range names {
+		switch strings.ToLower(os.Getenv(name)) {
+		case "", "false", "0":
+		default:
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands are the commands tried to copy to the clipboard on each
+// platform, in order, the first one installed is used.
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	},
+}
+
+// copyToClipboard copies the text to the system clipboard, with the
+// configured shell command when there is one, reading the text from stdin.
+func copyToClipboard(cfg *Config, text string) error {
+	var cmd *exec.Cmd
+	if cfg.ClipboardCommand != "" {
+		cmd = exec.Command("sh", "-c", cfg.ClipboardCommand)
+	} else {
+		args := clipboardTool()
+		if args == nil {
+			return fmt.Errorf("no clipboard tool found for %s, set clipboard_command", runtime.GOOS)
+		}
+		cmd = exec.Command(args[0], args[1:]...)
+	}
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("clipboard command failed: %w", err)
+	}
+
+	return nil
+}
+
+// clipboardTool is the first installed clipboard command of the platform, or
+// nil. wl-copy only works in a Wayland session.
+func clipboardTool() []string {
+	for _, args := range clipboardCommands[runtime.GOOS] {
+		if args[0] == "wl-copy" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			continue
+		}
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return args
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Defaults of the issue-closing footer.
+const defaultClosesFormat = "Closes #%s"
+
+var defaultClosesTypes = []string{"fix"}
+
+var (
+	reIssueReference = regexp.MustCompile(`#(\d+)\b`)
+	reClosingKeyword = regexp.MustCompile(`(?i)^(close[sd]?|fix(e[sd])?|resolve[sd]?)\b`)
+)
+
+// closesFooter adds a footer closing the issue of the change, found in the
+// branch name or, failing that, referenced in the body, when the commit type
+// is one that closes issues. A message already closing it is left alone.
+func closesFooter(message, branch, format string, types []string) string {
+	matches := reConventionalHeader.FindStringSubmatch(strings.SplitN(message, "\n", 2)[0])
+	if matches == nil || !slices.Contains(types, strings.ToLower(matches[1])) {
+		return message
+	}
+
+	number := branchIssueNumber(branch)
+	if number == "" {
+		_, body, _ := strings.Cut(message, "\n")
+		if reference := reIssueReference.FindStringSubmatch(body); reference != nil {
+			number = reference[1]
+		}
+	}
+	if number == "" || closesIssue(message, number) {
+		return message
+	}
+
+	return appendTrailer(message, fmt.Sprintf(format, number))
+}
+
+// closesIssue reports whether a line of the message body already closes the
+// issue, like "Fixes #12" or "Closes: #12".
+func closesIssue(message, number string) bool {
+	_, body, _ := strings.Cut(message, "\n")
+	for _, line := range strings.Split(body, "\n") {
+		if reClosingKeyword.MatchString(strings.TrimSpace(line)) && strings.Contains(line, "#"+number) {
+			return true
+		}
+	}
+
+	return false
+}
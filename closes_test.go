@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestClosesFooter(t *testing.T) {
+	types := []string{"fix"}
+	tests := []struct {
+		name    string
+		message string
+		branch  string
+		want    string
+	}{
+		{"from the branch", "fix: handle nil", "fix/12-nil", "fix: handle nil\n\nCloses #12"},
+		{"from the body", "fix: handle nil\n\nIt crashed when the list was empty, see #7.", "main", "fix: handle nil\n\nIt crashed when the list was empty, see #7.\n\nCloses #7"},
+		{"already closed", "fix: handle nil\n\nFixes #12", "fix/12-nil", "fix: handle nil\n\nFixes #12"},
+		{"other type", "feat: add x", "feat/12-x", "feat: add x"},
+		{"no issue", "fix: handle nil", "main", "fix: handle nil"},
+		{"jira key", "fix: handle nil", "ABC-12-issue-34", "fix: handle nil\n\nCloses #34"},
+		{"jira key alone", "fix: handle nil", "ABC-12-nil", "fix: handle nil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := closesFooter(tt.message, tt.branch, defaultClosesFormat, types); got != tt.want {
+				t.Errorf("closesFooter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosesFooterWithSkeleton(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	runGit(t, "checkout", "-q", "-b", "ABC-12-issue-34")
+
+	cfg := &Config{
+		ClosesIssue:    true,
+		ClosesFormat:   defaultClosesFormat,
+		ClosesTypes:    defaultClosesTypes,
+		Skeleton:       &Skeleton{TicketPattern: `[A-Z]+-\d+`},
+		PostProcessors: defaultPostProcessors,
+	}
+	want := "[ABC-12] fix: handle nil\n\nCloses #34"
+	if got := finishMessage(cfg, "fix: handle nil", nil, "", false, "m"); got != want {
+		t.Errorf("finishMessage() = %q, want %q", got, want)
+	}
+}
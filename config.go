@@ -0,0 +1,614 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli/v3"
+)
+
+// Config holds the settings that can be provided through config files and
+// overridden by command line flags.
+type Config struct {
+	Profile           string       `json:"profile"`
+	Provider          string       `json:"provider"`
+	APIKey            string       `json:"api_key"`
+	APIKeyFile        string       `json:"api_key_file"`
+	Model             string       `json:"model"`
+	SystemRole        string       `json:"system_role"`
+	APIPath           string       `json:"api_path"`
+	APIMethod         string       `json:"api_method"`
+	PromptFile        string       `json:"prompt_file"`
+	StyleGuide        string       `json:"style_guide"`
+	Language          string       `json:"language"`
+	Conventional      bool         `json:"conventional"`
+	HistoryStyle      string       `json:"history_style"`
+	HistoryAuthor     string       `json:"history_author"`
+	FetchIssue        bool         `json:"fetch_issue"`
+	DiffTarget        string       `json:"diff_target"`
+	DiffAlgorithm     string       `json:"diff_algorithm"`
+	ContextLines      *int         `json:"context_lines"`
+	FunctionContext   bool         `json:"function_context"`
+	MinimalDiff       bool         `json:"minimal_diff"`
+	MinDiffLines      int          `json:"min_diff_lines"`
+	FullDeletions     bool         `json:"full_deletions"`
+	StripDiffMetadata bool         `json:"strip_diff_metadata"`
+	SubmoduleLog      bool         `json:"submodule_log"`
+	SuggestSplit      bool         `json:"suggest_split"`
+	AllowAPIKeyInDiff bool         `json:"allow_api_key_in_diff"`
+	AnonymizePaths    bool         `json:"anonymize_paths"`
+	ValidateKey       bool         `json:"validate_key"`
+	SubjectOnly       bool         `json:"subject_only"`
+	Variant           string       `json:"variant"`
+	FenceDiff         bool         `json:"fence_diff"`
+	Base64Diff        bool         `json:"base64_diff"`
+	ASCIIOnly         bool         `json:"ascii_only"`
+	Bullets           bool         `json:"bullets"`
+	MaxBullets        int          `json:"max_bullets"`
+	WrapWidth         int          `json:"wrap"`
+	MaxSubjectLength  int          `json:"max_subject_length"`
+	OutputTemplate    string       `json:"output_template"`
+	Cache             bool         `json:"cache"`
+	MaxTokens         int          `json:"max_tokens"`
+	EmptyRetries      int          `json:"empty_retries"`
+	Candidates        int          `json:"candidates"`
+	Seed              *int         `json:"seed"`
+	Annotate          bool         `json:"annotate"`
+	Explain           bool         `json:"explain"`
+	Polish            bool         `json:"polish"`
+	SuggestOnMessage  bool         `json:"suggest_on_message"`
+	GeneratedBy       bool         `json:"generated_by"`
+	ClosesIssue       bool         `json:"closes_issue"`
+	ClosesFormat      string       `json:"closes_format"`
+	ClosesTypes       []string     `json:"closes_types"`
+	NotesRef          string       `json:"notes_ref"`
+	DetectReverts     bool         `json:"detect_reverts"`
+	DepsMessage       bool         `json:"deps_message"`
+	PostCommand       string       `json:"post_command"`
+	ClipboardCommand  string       `json:"clipboard_command"`
+	NoReasoning       bool         `json:"no_reasoning"`
+	Strict            bool         `json:"strict"`
+	Validate          bool         `json:"validate"`
+	ForceCI           bool         `json:"force_ci"`
+	CIEnvVars         []string     `json:"ci_env_vars"`
+	BadMessages       []string     `json:"bad_messages"`
+	Interactive       bool         `json:"interactive"`
+	Verbose           bool         `json:"verbose"`
+	Report            bool         `json:"report"`
+	DebugLog          string       `json:"debug_log"`
+	DetectLanguages   bool         `json:"detect_languages"`
+	Hints             []PromptHint `json:"hints"`
+	SensitivePaths    []string     `json:"sensitive_paths"`
+	Scopes            []ScopeRule  `json:"scopes"`
+	AutoScope         bool         `json:"auto_scope"`
+	Skeleton          *Skeleton    `json:"skeleton"`
+
+	// Vars are custom variables for the prompt template, {{.Vars.KEY}}
+	Vars map[string]string `json:"vars"`
+
+	// ModelAliases map short names to model IDs, for any of the models below
+	ModelAliases map[string]string `json:"model_aliases"`
+
+	// SmallModel and LargeModel replace the default model for diffs with
+	// fewer, or at least, ModelSwitchLines changed lines
+	SmallModel       string `json:"small_model"`
+	LargeModel       string `json:"large_model"`
+	ModelSwitchLines int    `json:"model_switch_lines"`
+
+	// Prices maps model names to their price, used to estimate the cost of
+	// each request, MaxCost is the budget for a single request in USD
+	Prices  map[string]Price `json:"prices"`
+	MaxCost float64          `json:"max_cost"`
+
+	// RPS paces the requests to the model API, 0 for no limit, Concurrency
+	// bounds how many batch features send at once
+	RPS         float64 `json:"rps"`
+	Concurrency int     `json:"concurrency"`
+
+	// MapReduce summarizes diffs larger than MapReduceThreshold bytes file
+	// by file, and writes the message from the summaries
+	MapReduce          bool `json:"map_reduce"`
+	MapReduceThreshold int  `json:"map_reduce_threshold"`
+
+	// BlankLines separate the generated message from the existing content of
+	// the commit message file, followed by the Separator line if there is one
+	BlankLines int    `json:"blank_lines"`
+	Separator  string `json:"separator"`
+
+	// ReasoningPatterns are regular expressions for thinking blocks to strip
+	// from the response, replacing the built-in ones when set
+	ReasoningPatterns []string `json:"reasoning_patterns"`
+
+	// DisclaimerPatterns are regular expressions for trailing lines to strip
+	// from the response, replacing the built-in ones when set
+	DisclaimerPatterns []string `json:"disclaimer_patterns"`
+
+	// PostProcessors are the names of the cleanup steps to run on the
+	// response, in order, leaving out a step disables it
+	PostProcessors []string `json:"post_processors"`
+
+	// UnsupportedFeatures are request features to leave out on top of what
+	// the provider doesn't support, for gateways that reject more
+	UnsupportedFeatures []string `json:"unsupported_features"`
+
+	// EntropyThreshold masks tokens of added lines with at least this many
+	// bits of entropy per character, 0 to turn it off, EntropyAllowlist are
+	// patterns of tokens never masked
+	EntropyThreshold float64  `json:"entropy_threshold"`
+	EntropyAllowlist []string `json:"entropy_allowlist"`
+
+	// Gemini native only
+	SafetyOff       bool              `json:"safety_off"`
+	SafetyThreshold string            `json:"safety_threshold"`
+	SafetySettings  map[string]string `json:"safety_settings"`
+	TopP            *float64          `json:"top_p"`
+	TopK            *int              `json:"top_k"`
+
+	// ExtraInstructions are added to the prompt by commands for a single
+	// invocation, they can't be configured
+	ExtraInstructions []string `json:"-"`
+
+	// HistoryBase is the existing commit being described, set by commands
+	// rewriting history, so the style examples come from before it
+	HistoryBase string `json:"-"`
+
+	// DiffSummary stands in for a diff too large to send, summarized file
+	// by file with --map-reduce
+	DiffSummary string `json:"-"`
+
+	// Sources records where each setting was last set, by its config key,
+	// settings without an entry have their default value
+	Sources map[string]string `json:"-"`
+
+	// Profiles are named bundles of the settings above, applied on top of
+	// the config files when selected
+	Profiles map[string]json.RawMessage `json:"profiles"`
+}
+
+// PromptHint adds extra guidance to the prompt when a changed file matches
+// Match, either by its full path or by its base name (e.g. "*.sql",
+// "Dockerfile", "migrations/*").
+type PromptHint struct {
+	Match string `json:"match"`
+	Hint  string `json:"hint"`
+}
+
+// configPaths returns the config files in the order they are applied, later
+// files overriding earlier ones.
+func configPaths() []string {
+	paths := []string{}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "commitment", "config.json"))
+	}
+	if root := getRepoRoot(); root != "" {
+		paths = append(paths, filepath.Join(root, ".commitment", "config.json"))
+	}
+
+	return paths
+}
+
+// loadConfig reads the global and repository config files and applies the
+// selected profile and any flags explicitly set on the command.
+func loadConfig(cmd *cli.Command) (*Config, error) {
+	cfg, err := loadConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	overrideString(cmd, "profile", &cfg.Profile)
+	if cfg.Profile != "" {
+		profile, ok := cfg.Profiles[cfg.Profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", cfg.Profile)
+		}
+		if err := json.Unmarshal(profile, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse profile %s: %w", cfg.Profile, err)
+		}
+		cfg.recordSources(profile, "profile "+cfg.Profile)
+	}
+
+	overrideString(cmd, "provider", &cfg.Provider)
+	overrideString(cmd, "model", &cfg.Model)
+	overrideString(cmd, "system-role", &cfg.SystemRole)
+	overrideString(cmd, "api-path", &cfg.APIPath)
+	overrideString(cmd, "api-method", &cfg.APIMethod)
+	overrideString(cmd, "prompt-file", &cfg.PromptFile)
+	overrideString(cmd, "style-guide", &cfg.StyleGuide)
+	if cmd.IsSet("var") {
+		if cfg.Vars == nil {
+			cfg.Vars = map[string]string{}
+		}
+		for key, value := range cmd.StringMap("var") {
+			cfg.Vars[key] = value
+		}
+	}
+	overrideString(cmd, "language", &cfg.Language)
+	overrideBool(cmd, "conventional", &cfg.Conventional)
+	overrideString(cmd, "history-style", &cfg.HistoryStyle)
+	overrideBool(cmd, "auto-scope", &cfg.AutoScope)
+	overrideString(cmd, "history-author", &cfg.HistoryAuthor)
+	overrideBool(cmd, "fetch-issue", &cfg.FetchIssue)
+	overrideBool(cmd, "detect-languages", &cfg.DetectLanguages)
+	overrideString(cmd, "diff-target", &cfg.DiffTarget)
+	overrideString(cmd, "diff-algorithm", &cfg.DiffAlgorithm)
+	if cmd.IsSet("context-lines") {
+		contextLines := int(cmd.Int("context-lines"))
+		cfg.ContextLines = &contextLines
+	}
+	overrideBool(cmd, "function-context", &cfg.FunctionContext)
+	overrideBool(cmd, "minimal-diff", &cfg.MinimalDiff)
+	overrideInt(cmd, "min-diff-lines", &cfg.MinDiffLines)
+	overrideBool(cmd, "full-deletions", &cfg.FullDeletions)
+	overrideBool(cmd, "strip-diff-metadata", &cfg.StripDiffMetadata)
+	overrideBool(cmd, "submodule-log", &cfg.SubmoduleLog)
+	overrideBool(cmd, "suggest-split", &cfg.SuggestSplit)
+	overrideBool(cmd, "subject-only", &cfg.SubjectOnly)
+	overrideString(cmd, "variant", &cfg.Variant)
+	overrideBool(cmd, "fence-diff", &cfg.FenceDiff)
+	overrideBool(cmd, "base64-diff", &cfg.Base64Diff)
+	overrideBool(cmd, "allow-api-key-in-diff", &cfg.AllowAPIKeyInDiff)
+	overrideBool(cmd, "anonymize-paths", &cfg.AnonymizePaths)
+	overrideBool(cmd, "validate-key", &cfg.ValidateKey)
+	overrideBool(cmd, "ascii-only", &cfg.ASCIIOnly)
+	overrideBool(cmd, "bullets", &cfg.Bullets)
+	overrideInt(cmd, "max-bullets", &cfg.MaxBullets)
+	overrideInt(cmd, "wrap", &cfg.WrapWidth)
+	overrideInt(cmd, "max-subject-length", &cfg.MaxSubjectLength)
+	overrideBool(cmd, "cache", &cfg.Cache)
+	overrideInt(cmd, "max-tokens", &cfg.MaxTokens)
+	overrideInt(cmd, "empty-retries", &cfg.EmptyRetries)
+	overrideInt(cmd, "candidates", &cfg.Candidates)
+	if cmd.IsSet("max-cost") {
+		cfg.MaxCost = cmd.Float("max-cost")
+	}
+	if cmd.IsSet("entropy-threshold") {
+		cfg.EntropyThreshold = cmd.Float("entropy-threshold")
+	}
+	if cmd.IsSet("rps") {
+		cfg.RPS = cmd.Float("rps")
+	}
+	overrideInt(cmd, "concurrency", &cfg.Concurrency)
+	overrideBool(cmd, "map-reduce", &cfg.MapReduce)
+	overrideInt(cmd, "map-reduce-threshold", &cfg.MapReduceThreshold)
+	if cmd.IsSet("seed") {
+		seed := int(cmd.Int("seed"))
+		cfg.Seed = &seed
+	}
+	overrideBool(cmd, "annotate", &cfg.Annotate)
+	overrideBool(cmd, "explain", &cfg.Explain)
+	overrideBool(cmd, "polish", &cfg.Polish)
+	overrideBool(cmd, "force-ci", &cfg.ForceCI)
+	overrideBool(cmd, "suggest-on-message", &cfg.SuggestOnMessage)
+	overrideBool(cmd, "generated-by", &cfg.GeneratedBy)
+	overrideBool(cmd, "closes-issue", &cfg.ClosesIssue)
+	overrideBool(cmd, "detect-reverts", &cfg.DetectReverts)
+	overrideBool(cmd, "deps-message", &cfg.DepsMessage)
+	overrideString(cmd, "post-command", &cfg.PostCommand)
+	overrideString(cmd, "clipboard-command", &cfg.ClipboardCommand)
+	overrideBool(cmd, "no-reasoning", &cfg.NoReasoning)
+	overrideBool(cmd, "strict", &cfg.Strict)
+	overrideBool(cmd, "validate", &cfg.Validate)
+	overrideBool(cmd, "interactive", &cfg.Interactive)
+	overrideBool(cmd, "verbose", &cfg.Verbose)
+	overrideBool(cmd, "report", &cfg.Report)
+	overrideString(cmd, "debug-log", &cfg.DebugLog)
+	overrideBool(cmd, "safety-off", &cfg.SafetyOff)
+	overrideString(cmd, "safety-threshold", &cfg.SafetyThreshold)
+	if cmd.IsSet("top-p") {
+		topP := cmd.Float("top-p")
+		cfg.TopP = &topP
+	}
+	if cmd.IsSet("top-k") {
+		topK := int(cmd.Int("top-k"))
+		cfg.TopK = &topK
+	}
+
+	for _, flag := range cmd.Root().Flags {
+		name := flag.Names()[0]
+		if !cmd.IsSet(name) {
+			continue
+		}
+		key := strings.ReplaceAll(name, "-", "_")
+		if name == "var" {
+			key = "vars"
+		}
+		cfg.Sources[key] = "flag --" + name
+	}
+
+	// A preset comes with its own model, unless one was chosen along with it.
+	// A model set before the provider, like the Gemini default the starter
+	// config pins, was meant for another provider.
+	if preset, ok := providerPresets[cfg.Provider]; ok &&
+		(cfg.Model == defaultModel || sourceRank(cfg.Sources["model"]) < sourceRank(cfg.Sources["provider"])) {
+		cfg.Model = preset.Model
+		cfg.Sources["model"] = "preset " + cfg.Provider
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxTokens < lowTokenBudget {
+		fmt.Fprintf(os.Stderr, "⚠️ max_tokens %d will likely cut off the subject line, consider at least %d\n", cfg.MaxTokens, lowTokenBudget)
+	}
+
+	if cfg.AnonymizePaths {
+		fmt.Fprintln(os.Stderr, "⚠️ File paths are anonymized, expect less specific messages")
+	}
+
+	cfg.disableUnsupported()
+
+	return cfg, nil
+}
+
+// validate checks the settings for values that can't work.
+func (c *Config) validate() error {
+	switch c.Provider {
+	case providerOpenAI, providerGeminiNative:
+	default:
+		if _, ok := providerPresets[c.Provider]; !ok {
+			return fmt.Errorf("unknown provider %q", c.Provider)
+		}
+	}
+
+	if !strings.HasPrefix(c.APIPath, "/") {
+		return fmt.Errorf("api_path must start with /, got %q", c.APIPath)
+	}
+
+	switch c.APIMethod {
+	case "POST", "PUT", "PATCH":
+	default:
+		return fmt.Errorf("unsupported API method %q, use POST, PUT or PATCH", c.APIMethod)
+	}
+
+	switch c.SystemRole {
+	case "", "system", "developer":
+	default:
+		return fmt.Errorf("unknown system role %q", c.SystemRole)
+	}
+
+	switch c.Variant {
+	case "", "short", "long":
+	default:
+		return fmt.Errorf("unknown variant %q", c.Variant)
+	}
+
+	switch c.HistoryStyle {
+	case "", "author", "repo", "blend":
+	default:
+		return fmt.Errorf("unknown history style %q", c.HistoryStyle)
+	}
+
+	switch c.DiffTarget {
+	case diffTargetStaged, diffTargetWorking, diffTargetHead:
+	default:
+		return fmt.Errorf("unknown diff target %q", c.DiffTarget)
+	}
+
+	switch c.DiffAlgorithm {
+	case "", "myers", "default", "minimal", "patience", "histogram":
+	default:
+		return fmt.Errorf("unknown diff algorithm %q", c.DiffAlgorithm)
+	}
+
+	if c.EntropyThreshold < 0 {
+		return fmt.Errorf("entropy_threshold can't be negative, got %g", c.EntropyThreshold)
+	}
+	for _, pattern := range c.EntropyAllowlist {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid entropy allowlist pattern %q: %w", pattern, err)
+		}
+	}
+
+	if c.RPS < 0 {
+		return fmt.Errorf("rps can't be negative, got %g", c.RPS)
+	}
+
+	if c.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", c.Concurrency)
+	}
+
+	if c.MapReduceThreshold < 1 {
+		return fmt.Errorf("map_reduce_threshold must be at least 1, got %d", c.MapReduceThreshold)
+	}
+
+	if c.MaxSubjectLength < 0 {
+		return fmt.Errorf("max_subject_length can't be negative, got %d", c.MaxSubjectLength)
+	}
+
+	if c.BlankLines < 0 {
+		return fmt.Errorf("blank_lines can't be negative, got %d", c.BlankLines)
+	}
+
+	// Anything but a comment would end up in the commit
+	if c.Separator != "" {
+		for _, line := range strings.Split(strings.TrimRight(c.Separator, "\r\n"), "\n") {
+			if !strings.HasPrefix(line, "#") {
+				return fmt.Errorf("separator lines must be comments starting with #, got %q", line)
+			}
+		}
+	}
+
+	if c.Skeleton != nil {
+		if _, err := regexp.Compile(c.Skeleton.TicketPattern); err != nil {
+			return fmt.Errorf("invalid ticket pattern %q: %w", c.Skeleton.TicketPattern, err)
+		}
+	}
+
+	for _, pattern := range c.ReasoningPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid reasoning pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range c.DisclaimerPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid disclaimer pattern %q: %w", pattern, err)
+		}
+	}
+
+	if _, err := template.New("output").Parse(c.OutputTemplate); err != nil {
+		return fmt.Errorf("invalid output template: %w", err)
+	}
+
+	for _, feature := range c.UnsupportedFeatures {
+		if !slices.Contains(allFeatures, feature) {
+			return fmt.Errorf("unknown feature %q", feature)
+		}
+	}
+
+	for _, name := range c.PostProcessors {
+		if !slices.Contains(defaultPostProcessors, name) {
+			return fmt.Errorf("unknown post-processor %q", name)
+		}
+	}
+
+	if strings.Count(c.ClosesFormat, "%s") != 1 || strings.Count(c.ClosesFormat, "%") != 1 {
+		return fmt.Errorf("closes_format needs exactly one %%s for the issue number, got %q", c.ClosesFormat)
+	}
+
+	if c.MaxTokens < 1 {
+		return fmt.Errorf("max_tokens must be at least 1, got %d", c.MaxTokens)
+	}
+
+	return nil
+}
+
+// loadConfigFiles reads the defaults and the global and repository config
+// files, without any profile or flags applied.
+func loadConfigFiles() (*Config, error) {
+	// The defaults are cloned, unmarshaling into the config overwrites the
+	// slices in place
+	cfg := &Config{
+		Provider:          providerOpenAI,
+		Model:             defaultModel,
+		APIPath:           defaultAPIPath,
+		APIMethod:         defaultAPIMethod,
+		Conventional:      true,
+		StripDiffMetadata: true,
+		DetectReverts:     true,
+		WrapWidth:         defaultWrapWidth,
+		BlankLines:        1,
+		MaxTokens:         maxTokens,
+		EmptyRetries:      1,
+		ClosesFormat:      defaultClosesFormat,
+		ClosesTypes:       slices.Clone(defaultClosesTypes),
+		NotesRef:          defaultNotesRef,
+		DiffTarget:        diffTargetStaged,
+		Concurrency:       defaultConcurrency,
+
+		MapReduceThreshold: defaultMapReduceThreshold,
+
+		ReasoningPatterns:  slices.Clone(defaultReasoningPatterns),
+		DisclaimerPatterns: slices.Clone(defaultDisclaimerPatterns),
+		PostProcessors:     slices.Clone(defaultPostProcessors),
+		CIEnvVars:          slices.Clone(defaultCIEnvVars),
+		BadMessages:        slices.Clone(defaultBadMessages),
+		EntropyAllowlist:   slices.Clone(defaultEntropyAllowlist),
+		Sources:            map[string]string{},
+	}
+
+	for _, path := range configPaths() {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+		}
+		content = stripJSONComments(content)
+
+		if err := json.Unmarshal(content, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+		cfg.recordSources(content, path)
+	}
+
+	return cfg, nil
+}
+
+// stripJSONComments blanks out lines starting with //, so config files can
+// be commented. The lines are kept empty so parse errors point at the right
+// place.
+func stripJSONComments(content []byte) []byte {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			lines[i] = ""
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// recordSources attributes the keys present in a config document to source.
+func (c *Config) recordSources(content []byte, source string) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(content, &keys); err != nil {
+		return
+	}
+	for key := range keys {
+		c.Sources[key] = source
+	}
+}
+
+// sourceRank orders the sources of settings by precedence: the defaults,
+// the config files in the order they're read, the profile, then the flags.
+func sourceRank(source string) int {
+	switch {
+	case source == "":
+		return -1
+	case strings.HasPrefix(source, "flag --"):
+		return len(configPaths()) + 1
+	case strings.HasPrefix(source, "profile "):
+		return len(configPaths())
+	}
+
+	return slices.Index(configPaths(), source)
+}
+
+// hasGeminiSettings reports whether any of the gemini-native only settings
+// were configured.
+func (c *Config) hasGeminiSettings() bool {
+	return c.SafetyOff || c.SafetyThreshold != "" || len(c.SafetySettings) > 0 || c.TopP != nil || c.TopK != nil
+}
+
+func overrideBool(cmd *cli.Command, name string, dst *bool) {
+	if cmd.IsSet(name) {
+		*dst = cmd.Bool(name)
+	}
+}
+
+func overrideInt(cmd *cli.Command, name string, dst *int) {
+	if cmd.IsSet(name) {
+		*dst = int(cmd.Int(name))
+	}
+}
+
+func overrideString(cmd *cli.Command, name string, dst *string) {
+	if cmd.IsSet(name) {
+		*dst = cmd.String(name)
+	}
+}
+
+// expandHome resolves a leading "~/" to the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, rest)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+)
+
+var configCmd = &cli.Command{
+	Name:  "config",
+	Usage: "Print the effective configuration and where each setting comes from",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "list-profiles",
+			Usage: "list the configured profiles and check each for problems instead",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Bool("list-profiles") {
+			return listProfiles()
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("Failed to encode config: %w", err)
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &values); err != nil {
+			return fmt.Errorf("Failed to encode config: %w", err)
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, key := range keys {
+			source, ok := cfg.Sources[key]
+			if !ok {
+				source = "default"
+			}
+			value := string(values[key])
+			if key == "api_key" && cfg.APIKey != "" {
+				value = `"[REDACTED]"`
+			}
+			if key == "profiles" {
+				value = redactedProfiles(cfg.Profiles)
+			}
+			fmt.Fprintf(out, "%s\t%s\t%s\n", key, source, value)
+		}
+
+		// The key in use, wherever it was found
+		apiKey, source := resolveAPIKey(cfg)
+		if apiKey == "" {
+			apiKey, source = "not set", "-"
+		} else {
+			apiKey = "[REDACTED]"
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\n", "API key", source, apiKey)
+
+		return out.Flush()
+	},
+}
+
+// redactedProfiles encodes the profiles with their API keys masked, like the
+// top-level one. A profile that can't be read is masked as a whole.
+func redactedProfiles(profiles map[string]json.RawMessage) string {
+	if profiles == nil {
+		return "null"
+	}
+
+	redacted := make(map[string]any, len(profiles))
+	for name, profile := range profiles {
+		var settings map[string]json.RawMessage
+		if err := json.Unmarshal(profile, &settings); err != nil {
+			redacted[name] = "[REDACTED]"
+			continue
+		}
+		if key, ok := settings["api_key"]; ok && string(key) != `""` {
+			settings["api_key"] = json.RawMessage(`"[REDACTED]"`)
+		}
+		redacted[name] = settings
+	}
+
+	encoded, err := json.Marshal(redacted)
+	if err != nil {
+		return `"[REDACTED]"`
+	}
+
+	return string(encoded)
+}
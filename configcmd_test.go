@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConfigCmdRedactsAPIKeys(t *testing.T) {
+	writeFile(t, isolate(t), `{
+		"api_key": "sk-TOP-456",
+		"profiles": {
+			"work": {"api_key": "sk-SECRET-123", "model": "x"},
+			"home": {"model": "y"}
+		}
+	}`)
+
+	var err error
+	stdout := captureStdout(t, func() {
+		err = configCmd.Run(context.Background(), []string{"config"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"sk-SECRET-123", "sk-TOP-456"} {
+		if strings.Contains(stdout, key) {
+			t.Errorf("config printed the key %s:\n%s", key, stdout)
+		}
+	}
+	if !strings.Contains(stdout, `{"home":{"model":"y"},"work":{"api_key":"[REDACTED]","model":"x"}}`) {
+		t.Errorf("config lacks the redacted profiles:\n%s", stdout)
+	}
+}
+
+func TestRedactedProfiles(t *testing.T) {
+	tests := []struct {
+		profiles map[string]json.RawMessage
+		want     string
+	}{
+		{nil, "null"},
+		{map[string]json.RawMessage{"a": json.RawMessage(`{"api_key": ""}`)}, `{"a":{"api_key":""}}`},
+		{map[string]json.RawMessage{"a": json.RawMessage(`{"api_key": "sk-1"}`)}, `{"a":{"api_key":"[REDACTED]"}}`},
+		{map[string]json.RawMessage{"a": json.RawMessage(`"sk-1"`)}, `{"a":"[REDACTED]"}`},
+	}
+	for _, tt := range tests {
+		if got := redactedProfiles(tt.profiles); got != tt.want {
+			t.Errorf("redactedProfiles(%s) = %s, want %s", tt.profiles, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxContextBytes keeps the project context from eating the token budget.
+const maxContextBytes = 8 * 1024
+
+// maxStyleGuideBytes is enough for a page of rules, more tends to dilute them.
+const maxStyleGuideBytes = 4 * 1024
+
+// readProjectContext returns the persistent project guidance kept in
+// .commitment/context.md at the repository root, if there is any.
+func readProjectContext() string {
+	root := getRepoRoot()
+	if root == "" {
+		return ""
+	}
+
+	content, err := readCappedFile(filepath.Join(root, ".commitment", "context.md"), maxContextBytes)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "⚠️ Couldn't read project context: %s\n", err)
+		}
+		return ""
+	}
+
+	return content
+}
+
+// readCappedFile reads at most max bytes of the file, cut at the last full
+// line, and warns when anything was left out.
+func readCappedFile(path string, max int) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	text := string(content)
+	if len(text) > max {
+		fmt.Fprintf(os.Stderr, "⚠️ %s is larger than %d bytes, truncating it\n", path, max)
+
+		text = text[:max]
+		if cut := strings.LastIndex(text, "\n"); cut > 0 {
+			text = text[:cut]
+		}
+		// Don't leave half a character behind
+		text = strings.ToValidUTF8(text, "")
+	}
+
+	return strings.TrimSpace(text), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// charsPerToken is a rough average for English text and code, good enough
+// to estimate a prompt before sending it.
+const charsPerToken = 4
+
+// Price is the cost of a model in USD per million tokens.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// cost prices the given token counts.
+func (p Price) cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1_000_000
+}
+
+// checkBudget estimates the worst case cost of a completion, the prompt plus
+// the longest response allowed, and reports whether it fits the budget.
+func checkBudget(cfg *Config, completion CompletionRequest) bool {
+	price, ok := cfg.Prices[completion.Model]
+	if !ok {
+		if cfg.MaxCost > 0 {
+			fmt.Fprintf(os.Stderr, "⚠️ No price configured for %s, the budget can't be enforced\n", completion.Model)
+		}
+		return true
+	}
+
+	inputTokens := (len(completion.System) + len(completion.Prompt)) / charsPerToken
+	estimate := price.cost(inputTokens, completion.MaxTokens)
+	logVerbose(cfg, "💰 Estimated cost: up to $%.6f (~%d prompt tokens)", estimate, inputTokens)
+
+	if cfg.MaxCost > 0 && estimate > cfg.MaxCost {
+		fmt.Fprintf(os.Stderr, "❌ Estimated cost of $%.6f exceeds the budget of $%.6f, not sending the request\n", estimate, cfg.MaxCost)
+		return false
+	}
+
+	return true
+}
+
+// reportUsage prints the actual cost of a completion from the token usage
+// reported by the provider, and adds it to the run's report.
+func reportUsage(cfg *Config, model string, inputTokens, outputTokens int) {
+	report.addUsage(cfg, model, inputTokens, outputTokens)
+
+	price, ok := cfg.Prices[model]
+	if !ok || (inputTokens == 0 && outputTokens == 0) {
+		return
+	}
+
+	logVerbose(cfg, "💰 Cost: $%.6f (%d prompt + %d response tokens)", price.cost(inputTokens, outputTokens), inputTokens, outputTokens)
+}
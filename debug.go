@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// logVerbose reports a diagnostic detail on stderr when running verbosely.
+func logVerbose(cfg *Config, format string, args ...any) {
+	if cfg.Verbose {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// debugLog appends the full request and response of an API exchange to the
+// configured debug log. The API key is redacted everywhere, including the
+// bodies, in case a provider ever echoes it back.
+func debugLog(cfg *Config, apiKey string, req *http.Request, status int, response []byte) {
+	if cfg.DebugLog == "" {
+		return
+	}
+
+	var request []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			request, _ = io.ReadAll(body)
+		}
+	}
+
+	redact := func(text string) string {
+		if apiKey == "" {
+			return text
+		}
+		return strings.ReplaceAll(text, apiKey, "[REDACTED]")
+	}
+
+	entry := fmt.Sprintf("=== %s %s %s\n--- request\n%s\n--- response (status %d)\n%s\n\n",
+		time.Now().Format(time.RFC3339), req.Method, redact(req.URL.String()),
+		redact(string(request)), status, redact(string(response)))
+
+	file, err := os.OpenFile(expandHome(cfg.DebugLog), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't open debug log: %s\n", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't write debug log: %s\n", err)
+	}
+}
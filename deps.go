@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// manifestFiles are the dependency manifests and lock files, a change to
+// nothing else is a dependency update.
+var manifestFiles = []string{
+	"go.mod", "go.sum",
+	"package.json", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+	"composer.json", "composer.lock",
+}
+
+var (
+	// A requirement in go.mod, in a require block or on its own line
+	reGoRequirement = regexp.MustCompile(`^([-+])\s*(?:require\s+)?([^\s()]+)\s+(v\d\S*)`)
+	// A dependency in package.json or composer.json, with a version spec
+	reJSONRequirement = regexp.MustCompile(`^([-+])\s*"([^"]+)":\s*"([~^>=<v]*\d[^"]*)"`)
+)
+
+// DependencyBump is a dependency whose version changed.
+type DependencyBump struct {
+	Name string
+	From string
+	To   string
+}
+
+func (b DependencyBump) String() string {
+	return fmt.Sprintf("%s from %s to %s", b.Name, b.From, b.To)
+}
+
+// dependencyBumps finds the version changes of a diff touching nothing but
+// manifests and lock files. It's empty for any other change, or when the
+// manifests change more than versions, like adding or removing a dependency.
+func dependencyBumps(diff, files string) []DependencyBump {
+	paths := changedPaths(files)
+	if len(paths) == 0 {
+		return nil
+	}
+	for _, changed := range paths {
+		if !isManifest(changed) {
+			return nil
+		}
+	}
+
+	var bumps []DependencyBump
+	for _, file := range splitFileDiffs(diff) {
+		var pattern *regexp.Regexp
+		switch path.Base(file.Path) {
+		case "go.mod":
+			pattern = reGoRequirement
+		case "package.json", "composer.json":
+			pattern = reJSONRequirement
+		default:
+			// Lock files follow the manifests, their versions add nothing
+			continue
+		}
+
+		removed := map[string]string{}
+		added := map[string]string{}
+		var order []string
+		for _, line := range strings.Split(file.Diff, "\n") {
+			// A replace directive isn't a requirement
+			if strings.Contains(line, "=>") {
+				continue
+			}
+			matches := pattern.FindStringSubmatch(line)
+			// The version of the package itself isn't a dependency
+			if matches == nil || matches[2] == "version" {
+				continue
+			}
+			if matches[1] == "-" {
+				removed[matches[2]] = matches[3]
+				continue
+			}
+			added[matches[2]] = matches[3]
+			order = append(order, matches[2])
+		}
+		if len(removed) != len(added) {
+			return nil
+		}
+
+		for _, name := range order {
+			from, ok := removed[name]
+			if !ok {
+				return nil
+			}
+			if from != added[name] {
+				bumps = append(bumps, DependencyBump{Name: name, From: from, To: added[name]})
+			}
+		}
+	}
+
+	return bumps
+}
+
+// isManifest reports whether the path is a dependency manifest or lock file.
+func isManifest(changed string) bool {
+	for _, name := range manifestFiles {
+		if path.Base(changed) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dependencyMessage is the standard message of a dependency update, like
+// "chore(deps): bump X from a to b", listing the bumps in the body when there
+// are several.
+func dependencyMessage(bumps []DependencyBump, conventional bool) string {
+	prefix := "Bump "
+	if conventional {
+		prefix = "chore(deps): bump "
+	}
+
+	if len(bumps) == 1 {
+		return prefix + bumps[0].String()
+	}
+
+	lines := make([]string, 0, len(bumps))
+	for _, bump := range bumps {
+		lines = append(lines, "- Bump "+bump.String())
+	}
+
+	return fmt.Sprintf("%s%d dependencies\n\n%s", prefix, len(bumps), strings.Join(lines, "\n"))
+}
+
+// dependencyInstruction tells the model the change is a dependency update,
+// so its message is as terse as the standard one.
+func dependencyInstruction(bumps []DependencyBump) string {
+	lines := make([]string, 0, len(bumps))
+	for _, bump := range bumps {
+		lines = append(lines, "- "+bump.String())
+	}
+
+	return "This change only updates dependencies:\n" + strings.Join(lines, "\n") + "\n" +
+		"Write a terse message like \"bump <dependency> from <old> to <new>\", with the chore type and deps scope " +
+		"for Conventional Commits, and don't describe the lock file changes."
+}
+
+// detectedDependencyMessage is the standard message of a dependency update
+// when one is detected and --deps-message is set, or an empty string.
+func detectedDependencyMessage(cfg *Config, diff, files string) string {
+	if !cfg.DepsMessage {
+		return ""
+	}
+	bumps := dependencyBumps(diff, files)
+	if len(bumps) == 0 {
+		return ""
+	}
+
+	fmt.Fprintln(os.Stderr, "📦 The changes bump dependencies, using the standard message")
+	return dependencyMessage(bumps, cfg.Conventional)
+}
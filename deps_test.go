@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readDiff reads a diff fixture from testdata/deps.
+func readDiff(t *testing.T, name string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "deps", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+func TestDependencyBumps(t *testing.T) {
+	tests := []struct {
+		name  string
+		diff  string
+		files string
+		want  []DependencyBump
+	}{
+		{"go.mod", "go_mod.diff", "M\tgo.mod\nM\tgo.sum", []DependencyBump{
+			{"github.com/urfave/cli/v3", "v3.0.0-beta1", "v3.0.0-beta2"},
+			{"golang.org/x/text", "v0.15.0", "v0.16.0"},
+			{"golang.org/x/sys", "v0.20.0", "v0.21.0"},
+		}},
+		{"package.json", "package_json.diff", "M\tpackage.json\nM\tpackage-lock.json", []DependencyBump{
+			{"react", "^18.2.0", "^18.3.1"},
+		}},
+		{"added dependency", "package_json_added.diff", "M\tpackage.json", nil},
+		{"replace directive", "go_mod_replace.diff", "M\tgo.mod", nil},
+		{"code changed too", "go_mod.diff", "M\tgo.mod\nM\tgo.sum\nM\tmain.go", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dependencyBumps(readDiff(t, tt.diff), tt.files); !slices.Equal(got, tt.want) {
+				t.Errorf("dependencyBumps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependencyMessage(t *testing.T) {
+	one := []DependencyBump{{"react", "^18.2.0", "^18.3.1"}}
+	two := append(one, DependencyBump{"lodash", "4.17.20", "4.17.21"})
+	tests := []struct {
+		bumps        []DependencyBump
+		conventional bool
+		want         string
+	}{
+		{one, true, "chore(deps): bump react from ^18.2.0 to ^18.3.1"},
+		{one, false, "Bump react from ^18.2.0 to ^18.3.1"},
+		{two, true, "chore(deps): bump 2 dependencies\n\n- Bump react from ^18.2.0 to ^18.3.1\n- Bump lodash from 4.17.20 to 4.17.21"},
+	}
+	for _, tt := range tests {
+		if got := dependencyMessage(tt.bumps, tt.conventional); got != tt.want {
+			t.Errorf("dependencyMessage(%v, %v) = %q, want %q", tt.bumps, tt.conventional, got, tt.want)
+		}
+	}
+}
+
+func TestDetectedDependencyMessage(t *testing.T) {
+	diff := readDiff(t, "package_json.diff")
+	files := "M\tpackage.json\nM\tpackage-lock.json"
+
+	if got := detectedDependencyMessage(&Config{Conventional: true}, diff, files); got != "" {
+		t.Errorf("detectedDependencyMessage() = %q without --deps-message", got)
+	}
+	var got string
+	captureStderr(t, func() { got = detectedDependencyMessage(&Config{Conventional: true, DepsMessage: true}, diff, files) })
+	if got != "chore(deps): bump react from ^18.2.0 to ^18.3.1" {
+		t.Errorf("detectedDependencyMessage() = %q", got)
+	}
+	if got := detectedDependencyMessage(&Config{DepsMessage: true}, readDiff(t, "package_json_added.diff"), "M\tpackage.json"); got != "" {
+		t.Errorf("detectedDependencyMessage() = %q for an added dependency", got)
+	}
+}
+
+func TestDependencyInstruction(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	commitFile(t, "go.mod", "module example.com/app\n\ngo 1.23\n\nrequire golang.org/x/text v0.15.0\n", "chore: add go.mod")
+	writeFile(t, "go.mod", "module example.com/app\n\ngo 1.23\n\nrequire golang.org/x/text v0.16.0\n")
+	runGit(t, "add", "go.mod")
+
+	cfg := &Config{DiffTarget: diffTargetStaged}
+	if _, _, err := collectChanges(cfg, ""); err != nil {
+		t.Fatal(err)
+	}
+	if instructions := strings.Join(cfg.ExtraInstructions, "\n"); !strings.Contains(instructions, "This change only updates dependencies:\n- golang.org/x/text from v0.15.0 to v0.16.0\n") {
+		t.Errorf("instructions = %q", instructions)
+	}
+}
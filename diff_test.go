@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCollapseLongLines(t *testing.T) {
+	minified := "var a=1;" + strings.Repeat("function f(){return 0}", 100)
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{"short lines", "+a\n-b\n c", "+a\n-b\n c"},
+		{"added", "+" + minified, fmt.Sprintf("+<minified content, %d bytes changed>", len(minified))},
+		{"removed", "-" + minified, fmt.Sprintf("-<minified content, %d bytes changed>", len(minified))},
+		{"at the limit", "+" + strings.Repeat("x", maxDiffLineLength-1), "+" + strings.Repeat("x", maxDiffLineLength-1)},
+		{
+			"among other lines",
+			"@@ -1 +1 @@\n-" + minified + "\n+" + minified + "x\n",
+			fmt.Sprintf("@@ -1 +1 @@\n-<minified content, %d bytes changed>\n+<minified content, %d bytes changed>\n", len(minified), len(minified)+1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collapseLongLines(tt.diff); got != tt.want {
+				t.Errorf("collapseLongLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildUserPromptMinifiedFile(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	commitFile(t, "README.md", "# Test\n", "docs: add readme")
+	minified := strings.Repeat("!function(e){var t={};e.exports=t}(module);", 5000)
+	writeFile(t, "dist/app.min.js", minified+"\n")
+	writeFile(t, "README.md", "# Test\n\nBuild with make.\n")
+	runGit(t, "add", "-A")
+
+	cfg := &Config{DiffTarget: diffTargetStaged}
+	prompt := buildUserPrompt(cfg, getGitDiff(cfg), getChangedFiles(cfg))
+	if want := fmt.Sprintf("+<minified content, %d bytes changed>", len(minified)); !strings.Contains(prompt, want) {
+		t.Errorf("prompt lacks %q", want)
+	}
+	if !strings.Contains(prompt, "+Build with make.") {
+		t.Errorf("prompt lacks the README change")
+	}
+	if len(prompt) > 5000 {
+		t.Errorf("prompt is %d bytes long", len(prompt))
+	}
+}
+
+func TestStripDiffMetadata(t *testing.T) {
+	diff, err := os.ReadFile(filepath.Join("testdata", "metadata.diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"diff --git a/main.go b/main.go",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -1,3 +1,3 @@ package main",
+		" index := 0",
+		"-\\ this removed line starts like the marker",
+		"+index 0000000..1111111 is added text, not metadata",
+		"diff --git a/old.go b/new.go",
+		"rename from old.go",
+		"rename to new.go",
+		"--- a/old.go",
+		"+++ b/new.go",
+		"@@ -1 +1 @@",
+		"-package old",
+		"+package new",
+		"diff --git a/run.sh b/run.sh",
+		"old mode 100644",
+		"new mode 100755",
+		"diff --git a/logo.png b/logo.png",
+		"new file mode 100644",
+		"Binary files /dev/null and b/logo.png differ",
+		"",
+	}
+	if got := strings.Split(stripDiffMetadata(string(diff)), "\n"); !slices.Equal(got, want) {
+		t.Errorf("stripDiffMetadata() kept:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStripDiffMetadataOption(t *testing.T) {
+	diff, err := os.ReadFile(filepath.Join("testdata", "metadata.diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args     []string
+		stripped bool
+	}{
+		{nil, true},
+		{[]string{"--strip-diff-metadata=false"}, false},
+	}
+	for _, tt := range tests {
+		isolate(t)
+		cfg, err := loadTestConfig(t, tt.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		prompt := buildUserPrompt(cfg, string(diff), "M\tmain.go")
+		for _, line := range []string{"index 1111111..2222222 100644", "similarity index 90%", "\\ No newline at end of file"} {
+			if strings.Contains(prompt, line) == tt.stripped {
+				t.Errorf("with %q the prompt has %q = %v", tt.args, line, !tt.stripped)
+			}
+		}
+		if !strings.Contains(prompt, "@@ -1,3 +1,3 @@ package main") || !strings.Contains(prompt, "+++ b/new.go") {
+			t.Errorf("with %q the prompt lacks the headers:\n%s", tt.args, prompt)
+		}
+	}
+}
+
+// sqlDiff changes a migration, removing a SQL comment and adding a line
+// starting with ++.
+const sqlDiff = `diff --git a/schema.sql b/schema.sql
+index 1111111..2222222 100644
+--- a/schema.sql
++++ b/schema.sql
+@@ -1,3 +1,3 @@
+--- drop the users table first
++++counter
+ DROP TABLE users;
+-DROP TABLE posts;
+`
+
+func TestCountChangedLines(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want int
+	}{
+		{"empty", "", 0},
+		{"comments starting with -- and ++", sqlDiff, 3},
+		{"two files", sqlDiff + strings.Replace(sqlDiff, "schema.sql", "other.sql", -1), 6},
+		{"without a git header", "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countChangedLines(tt.diff); got != tt.want {
+				t.Errorf("countChangedLines() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeDeletedSQLFile(t *testing.T) {
+	diff := `diff --git a/schema.sql b/schema.sql
+deleted file mode 100644
+index 1111111..0000000
+--- a/schema.sql
++++ /dev/null
+@@ -1,3 +0,0 @@
+--- a/comment that looks like a header
+-DROP TABLE users;
+-DROP TABLE posts;
+`
+	want := "diff --git a/schema.sql b/schema.sql\ndeleted file schema.sql (3 lines)\n"
+	if got := summarizeDeletions(diff); got != want {
+		t.Errorf("summarizeDeletions() = %q, want %q", got, want)
+	}
+}
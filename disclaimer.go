@@ -0,0 +1,48 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// defaultDisclaimerPatterns match the conversational lines models like to
+// append after the actual message.
+var defaultDisclaimerPatterns = []string{
+	`(?i)^(this )?(commit )?message (was )?(generated|written) (by|with|using) (an )?ai\b`,
+	`(?i)^(let me know|feel free to|i hope this|hope this helps)`,
+	`(?i)^(would you like|do you want) me to\b`,
+	`(?i)^(note|disclaimer): .*\b(ai|generated|review)\b`,
+}
+
+// stripDisclaimers removes trailing lines matching any of the patterns, along
+// with the blank lines around them. Lines are only removed from the end so
+// the message itself is never touched.
+func stripDisclaimers(message string, patterns []string) string {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			compiled = append(compiled, re)
+		}
+	}
+
+	lines := strings.Split(message, "\n")
+	for len(lines) > 1 {
+		last := strings.TrimSpace(lines[len(lines)-1])
+		if last != "" && !matchesAny(compiled, last) {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func matchesAny(patterns []*regexp.Regexp, line string) bool {
+	for _, re := range patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// editorCmd is the editor `install --skip-editor` configures: it accepts a
+// commit message as it is and opens the user's own editor for everything
+// else git asks to edit, like the todo list of git rebase -i or a tag
+// message.
+var editorCmd = &cli.Command{
+	Name:      "editor",
+	Usage:     "Accept commit messages without editing, open the editor for anything else",
+	ArgsUsage: "file",
+	Hidden:    true,
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		path := cmd.Args().Get(0)
+		if filepath.Base(path) == "COMMIT_EDITMSG" {
+			return nil
+		}
+
+		return runEditor(userEditor(), path, false)
+	},
+}
+
+// shellQuote quotes the argument for sh.
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// skipEditorCommand is the core.editor `install --skip-editor` sets.
+func skipEditorCommand() string {
+	execPath, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+
+	return shellQuote(execPath) + " editor"
+}
+
+// skipsEditor reports whether git's editor is the one `install --skip-editor`
+// sets, which never shows a commit message.
+func skipsEditor() bool {
+	output, err := exec.Command("git", "var", "GIT_EDITOR").Output()
+	return err == nil && strings.TrimSpace(string(output)) == skipEditorCommand()
+}
+
+// userEditor is the editor the user set up, the way git picks it, past the
+// one `install --skip-editor` sets: core.editor, then VISUAL and EDITOR.
+func userEditor() string {
+	for _, args := range [][]string{{"config", "core.editor"}, {"config", "--global", "core.editor"}} {
+		output, err := exec.Command("git", args...).Output()
+		if editor := strings.TrimSpace(string(output)); err == nil && editor != "" && editor != skipEditorCommand() {
+			return editor
+		}
+	}
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(name); editor != "" {
+			return editor
+		}
+	}
+
+	return "vi"
+}
+
+// runEditor opens the editor on the file and waits for it to close. From a
+// hook, which git runs without a terminal, the editor is given the one the
+// commit was started from.
+func runEditor(editor, path string, fromHook bool) error {
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if fromHook {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return fmt.Errorf("no terminal to open the editor on: %w", err)
+		}
+		defer tty.Close()
+		cmd.Stdin = tty
+		cmd.Stdout = tty
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+
+	return nil
+}
+
+// configureSkipEditor sets the repository's core.editor to the one that
+// accepts commit messages as they are. An editor the user already set for
+// the repository is never replaced.
+func configureSkipEditor() error {
+	if skipEditorCommand() == "" {
+		return fmt.Errorf("Error: Failed to find the commitment executable")
+	}
+	output, _ := exec.Command("git", "config", "--local", "core.editor").Output()
+	if current := strings.TrimSpace(string(output)); current != "" && current != skipEditorCommand() {
+		return fmt.Errorf("Error: core.editor is already set to %q for this repository, unset it first with git config --unset core.editor", current)
+	}
+
+	if err := exec.Command("git", "config", "--local", "core.editor", skipEditorCommand()).Run(); err != nil {
+		return fmt.Errorf("Failed to configure the editor: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigureSkipEditor(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+
+	if !editorShown() {
+		t.Fatal("editorShown() = false before install")
+	}
+	for range 2 {
+		if err := configureSkipEditor(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !skipsEditor() || editorShown() {
+		t.Errorf("skipsEditor() = %v and editorShown() = %v with the editor set", skipsEditor(), editorShown())
+	}
+
+	t.Setenv("GIT_EDITOR", "nano")
+	if skipsEditor() || !editorShown() {
+		t.Errorf("GIT_EDITOR should win over core.editor")
+	}
+	t.Setenv("GIT_EDITOR", ":")
+	if editorShown() {
+		t.Errorf("editorShown() = true with GIT_EDITOR=:")
+	}
+}
+
+func TestConfigureSkipEditorKeepsTheUsersEditor(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	runGit(t, "config", "core.editor", "vim")
+
+	err := configureSkipEditor()
+	if err == nil || !strings.Contains(err.Error(), `"vim"`) {
+		t.Fatalf("configureSkipEditor() = %v, want it to refuse", err)
+	}
+	if editor := strings.TrimSpace(runGit(t, "config", "core.editor")); editor != "vim" {
+		t.Errorf("core.editor = %q, want it untouched", editor)
+	}
+}
+
+func TestUserEditor(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	if err := configureSkipEditor(); err != nil {
+		t.Fatal(err)
+	}
+
+	if editor := userEditor(); editor != "vi" {
+		t.Errorf("userEditor() = %q without one set, want vi", editor)
+	}
+	t.Setenv("EDITOR", "nano")
+	if editor := userEditor(); editor != "nano" {
+		t.Errorf("userEditor() = %q, want EDITOR", editor)
+	}
+	t.Setenv("VISUAL", "code --wait")
+	if editor := userEditor(); editor != "code --wait" {
+		t.Errorf("userEditor() = %q, want VISUAL", editor)
+	}
+	runGit(t, "config", "--global", "core.editor", "emacs")
+	if editor := userEditor(); editor != "emacs" {
+		t.Errorf("userEditor() = %q, want the global core.editor", editor)
+	}
+}
+
+func TestEditorCmd(t *testing.T) {
+	isolate(t)
+	dir := initRepo(t)
+	script := filepath.Join(dir, "edit.sh")
+	writeFile(t, script, "#!/bin/sh\necho edited > \"$1\"\n")
+	if err := os.Chmod(script, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", shellQuote(script))
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{".git/COMMIT_EDITMSG", "fix: keep it\n"},
+		{".git/rebase-merge/git-rebase-todo", "edited\n"},
+		{".git/TAG_EDITMSG", "edited\n"},
+		{".git/MERGE_MSG", "edited\n"},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.file), func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			writeFile(t, path, "fix: keep it\n")
+
+			if err := editorCmd.Run(context.Background(), []string{"editor", path}); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// explainInstruction asks for the message along with the reasoning behind
+// it, in the shape parseExplanation reads.
+const explainInstruction = `Respond with a JSON object with two string fields: "message", the complete commit message, ` +
+	`and "rationale", one or two sentences on why you chose this type, scope and wording.`
+
+// Explanation is the response to explainInstruction.
+type Explanation struct {
+	Message   string `json:"message"`
+	Rationale string `json:"rationale"`
+}
+
+// parseExplanation separates the message from the rationale. A response that
+// isn't the expected JSON is returned as the message, without a rationale.
+func parseExplanation(response string) (string, string) {
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return "", ""
+	}
+
+	var explanation Explanation
+	if err := json.Unmarshal([]byte(stripMarkdownFences(response)), &explanation); err != nil || explanation.Message == "" {
+		fmt.Fprintln(os.Stderr, "⚠️ The response didn't come with an explanation")
+		return response, ""
+	}
+
+	return explanation.Message, strings.TrimSpace(explanation.Rationale)
+}
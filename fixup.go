@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// autosquashPrefixes are the subject markers git commit --fixup and --squash
+// put in front of the target commit's subject.
+var autosquashPrefixes = []string{"fixup! ", "squash! "}
+
+// fixupSubject returns the subject of a commit message file prepared by git
+// commit --fixup or --squash, or an empty string for any other message. Only
+// untouched messages qualify, a body means the user already wrote one.
+func fixupSubject(commitMsgFile string) string {
+	content, err := os.ReadFile(commitMsgFile)
+	if err != nil {
+		return ""
+	}
+
+	subject, body, _ := splitCommitMessage(string(content))
+	if body != "" {
+		return ""
+	}
+	for _, prefix := range autosquashPrefixes {
+		if strings.HasPrefix(subject, prefix) {
+			return subject
+		}
+	}
+
+	return ""
+}
+
+// fixupInstruction tells the model the change amends an earlier commit, with
+// that commit's message for context when it can be found.
+func fixupInstruction(subject string) string {
+	target := subject
+	for _, prefix := range autosquashPrefixes {
+		target = strings.TrimPrefix(target, prefix)
+	}
+
+	instruction := fmt.Sprintf("This change is a fixup of the earlier commit %q and will be squashed into it. "+
+		"Keep this exact subject line: %s\nIn the body, describe only what this change corrects or adds to that commit.", target, subject)
+	if message := targetCommitMessage(target); message != "" {
+		instruction += "\n\nThe earlier commit message:\n" + message
+	}
+
+	return instruction
+}
+
+// targetCommitMessage finds the most recent commit with the given subject and
+// returns its full message.
+func targetCommitMessage(subject string) string {
+	cmd := exec.Command("git", "log", "-n", "50", "--format=%s%x00%B%x1e")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range strings.Split(string(output), "\x1e") {
+		entrySubject, message, found := strings.Cut(strings.TrimLeft(entry, "\n"), "\x00")
+		if found && entrySubject == subject {
+			return strings.TrimSpace(message)
+		}
+	}
+
+	return ""
+}
+
+// keepFixupSubject replaces whatever subject the model wrote with the one git
+// prepared, autosquash relies on it matching.
+func keepFixupSubject(subject, message string) string {
+	_, body, _ := strings.Cut(message, "\n")
+	if body = strings.TrimSpace(body); body == "" {
+		return subject
+	}
+
+	return subject + "\n\n" + body
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var geminiNativeEndpoint = "https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent"
+
+type GeminiRequest struct {
+	SystemInstruction *GeminiContent         `json:"system_instruction,omitempty"`
+	Contents          []GeminiContent        `json:"contents"`
+	GenerationConfig  GeminiGenerationConfig `json:"generationConfig"`
+	SafetySettings    []GeminiSafetySetting  `json:"safetySettings,omitempty"`
+}
+
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+type GeminiGenerationConfig struct {
+	MaxOutputTokens int      `json:"maxOutputTokens"`
+	Temperature     float64  `json:"temperature"`
+	Seed            *int     `json:"seed,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	TopK            *int     `json:"topK,omitempty"`
+
+	// ResponseMimeType "application/json" makes the model respond with JSON
+	ResponseMimeType string `json:"responseMimeType,omitempty"`
+
+	ThinkingConfig *GeminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type GeminiThinkingConfig struct {
+	ThinkingBudget int `json:"thinkingBudget"`
+}
+
+type GeminiSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+var geminiHarmCategories = []string{
+	"HARM_CATEGORY_HARASSMENT",
+	"HARM_CATEGORY_HATE_SPEECH",
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT",
+	"HARM_CATEGORY_DANGEROUS_CONTENT",
+}
+
+type GeminiResponse struct {
+	Candidates []struct {
+		Content      GeminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// completeWithGeminiNative generates the message through Gemini's own
+// generateContent API rather than the OpenAI-compatible layer. It also
+// reports whether the API answered, see completeAnswered.
+func completeWithGeminiNative(cfg *Config, completion CompletionRequest, apiKey string) (string, bool) {
+	requestData := GeminiRequest{
+		SystemInstruction: &GeminiContent{Parts: []GeminiPart{{Text: completion.System}}},
+		Contents: []GeminiContent{
+			{Role: "user", Parts: []GeminiPart{{Text: completion.Prompt}}},
+		},
+		GenerationConfig: GeminiGenerationConfig{
+			MaxOutputTokens: completion.MaxTokens,
+			Temperature:     cfg.temperature() + completion.TemperatureBoost,
+			Seed:            cfg.Seed,
+			TopP:            cfg.TopP,
+			TopK:            cfg.TopK,
+		},
+		SafetySettings: geminiSafetySettings(cfg),
+	}
+	if cfg.NoReasoning {
+		requestData.GenerationConfig.ThinkingConfig = &GeminiThinkingConfig{ThinkingBudget: 0}
+	}
+	if completion.JSON {
+		requestData.GenerationConfig.ResponseMimeType = "application/json"
+	}
+
+	geminiResp := sendGeminiRequest(cfg, completion.Model, requestData, apiKey)
+	if geminiResp == nil {
+		return "", false
+	}
+
+	if len(geminiResp.Candidates) == 0 {
+		if reason := geminiResp.PromptFeedback.BlockReason; reason != "" {
+			fmt.Fprintf(os.Stderr, "❌ Prompt blocked by the provider's content filter (%s)\n", reason)
+		} else {
+			fmt.Fprintln(os.Stderr, "❌ No message generated, the API returned no candidates")
+		}
+		return "", false
+	}
+
+	var text strings.Builder
+	for _, part := range geminiResp.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+
+	if strings.TrimSpace(text.String()) == "" {
+		reportEmptyCompletion(geminiResp.Candidates[0].FinishReason)
+	}
+
+	return trimTruncated(text.String(), geminiResp.Candidates[0].FinishReason), true
+}
+
+// geminiSafetySettings resolves the configured thresholds per category. A
+// blanket threshold applies to every category, specific settings win over it.
+func geminiSafetySettings(cfg *Config) []GeminiSafetySetting {
+	threshold := cfg.SafetyThreshold
+	if cfg.SafetyOff {
+		threshold = "BLOCK_NONE"
+	}
+
+	settings := []GeminiSafetySetting{}
+	for _, category := range geminiHarmCategories {
+		categoryThreshold := threshold
+		if specific, ok := cfg.SafetySettings[category]; ok && !cfg.SafetyOff {
+			categoryThreshold = specific
+		}
+		if categoryThreshold != "" {
+			settings = append(settings, GeminiSafetySetting{Category: category, Threshold: categoryThreshold})
+		}
+	}
+
+	return settings
+}
+
+// sendGeminiRequest posts the request to the generateContent endpoint for the
+// given model and decodes the response, reporting any failure.
+func sendGeminiRequest(cfg *Config, model string, requestData GeminiRequest, apiKey string) *GeminiResponse {
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error creating JSON request: %s\n", err)
+		return nil
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf(geminiNativeEndpoint, model), bytes.NewBuffer(jsonData))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error creating HTTP request: %s\n", err)
+		return nil
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", apiKey)
+	req.Header.Set("User-Agent", userAgent())
+
+	waitForRequest(cfg)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		debugLog(cfg, apiKey, req, 0, []byte(err.Error()))
+		fmt.Fprintf(os.Stderr, "❌ Error sending request: %s\n", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	debugLog(cfg, apiKey, req, resp.StatusCode, body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading response: %s\n", err)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "❌ API error (status %d): %s\n", resp.StatusCode, body)
+		return nil
+	}
+
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error parsing response: %s\n", err)
+		return nil
+	}
+
+	reportUsage(cfg, model, geminiResp.UsageMetadata.PromptTokenCount, geminiResp.UsageMetadata.CandidatesTokenCount)
+	return &geminiResp
+}
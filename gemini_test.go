@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeGemini serves the generateContent API with the recorded response from
+// testdata/gemini. It returns the decoded request and the path it was sent to.
+func fakeGemini(t *testing.T, fixture string) (*GeminiRequest, *string) {
+	t.Helper()
+
+	response, err := os.ReadFile(filepath.Join("testdata", "gemini", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var request GeminiRequest
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.Header.Get("x-goog-api-key") != "key" {
+			t.Errorf("API key header = %q", r.Header.Get("x-goog-api-key"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("invalid request: %s", err)
+		}
+		w.Write(response)
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := geminiNativeEndpoint
+	geminiNativeEndpoint = server.URL + "/v1beta/models/%s:generateContent"
+	t.Cleanup(func() { geminiNativeEndpoint = endpoint })
+
+	return &request, &path
+}
+
+func TestCompleteWithGeminiNative(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{"response.json", "feat(api): add pagination to the users endpoint\n\nLarge accounts timed out listing users."},
+		{"max_tokens.json", "fix: handle empty lists\n\nThe renderer crashed when the list"},
+		{"blocked.json", ""},
+		{"safety.json", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			cfg := &Config{Provider: providerGeminiNative}
+			_, path := fakeGemini(t, tt.fixture)
+
+			got, _ := completeWithGeminiNative(cfg, CompletionRequest{Model: "gemini-2.0-flash", System: "system", Prompt: "diff", MaxTokens: 120}, "key")
+			if got != tt.want {
+				t.Errorf("completeWithGeminiNative() = %q, want %q", got, tt.want)
+			}
+			if *path != "/v1beta/models/gemini-2.0-flash:generateContent" {
+				t.Errorf("sent to %s", *path)
+			}
+		})
+	}
+}
+
+func TestGeminiNativeRequest(t *testing.T) {
+	seed, topK, topP := 7, 40, 0.9
+	cfg := &Config{
+		Provider:        providerGeminiNative,
+		Seed:            &seed,
+		TopP:            &topP,
+		TopK:            &topK,
+		NoReasoning:     true,
+		SafetyThreshold: "BLOCK_ONLY_HIGH",
+		SafetySettings:  map[string]string{"HARM_CATEGORY_HARASSMENT": "BLOCK_NONE"},
+	}
+	request, _ := fakeGemini(t, "response.json")
+
+	completeWithGeminiNative(cfg, CompletionRequest{Model: "gemini-2.5-flash", System: "system", Prompt: "diff", MaxTokens: 120, JSON: true}, "key")
+
+	if request.SystemInstruction == nil || request.SystemInstruction.Parts[0].Text != "system" {
+		t.Errorf("system instruction = %+v", request.SystemInstruction)
+	}
+	if len(request.Contents) != 1 || request.Contents[0].Role != "user" || request.Contents[0].Parts[0].Text != "diff" {
+		t.Errorf("contents = %+v", request.Contents)
+	}
+	generation := request.GenerationConfig
+	if generation.MaxOutputTokens != 120 || generation.Temperature != 0 || *generation.Seed != 7 || *generation.TopP != 0.9 || *generation.TopK != 40 {
+		t.Errorf("generationConfig = %+v", generation)
+	}
+	if generation.ResponseMimeType != "application/json" {
+		t.Errorf("responseMimeType = %q", generation.ResponseMimeType)
+	}
+	if generation.ThinkingConfig == nil || generation.ThinkingConfig.ThinkingBudget != 0 {
+		t.Errorf("thinkingConfig = %+v", generation.ThinkingConfig)
+	}
+
+	want := map[string]string{
+		"HARM_CATEGORY_HARASSMENT":        "BLOCK_NONE",
+		"HARM_CATEGORY_HATE_SPEECH":       "BLOCK_ONLY_HIGH",
+		"HARM_CATEGORY_SEXUALLY_EXPLICIT": "BLOCK_ONLY_HIGH",
+		"HARM_CATEGORY_DANGEROUS_CONTENT": "BLOCK_ONLY_HIGH",
+	}
+	if len(request.SafetySettings) != len(want) {
+		t.Fatalf("safetySettings = %+v", request.SafetySettings)
+	}
+	for _, setting := range request.SafetySettings {
+		if want[setting.Category] != setting.Threshold {
+			t.Errorf("%s threshold = %q, want %q", setting.Category, setting.Threshold, want[setting.Category])
+		}
+	}
+}
+
+func TestGeminiSafetySettings(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want int
+		all  string
+	}{
+		{"none", Config{}, 0, ""},
+		{"threshold", Config{SafetyThreshold: "BLOCK_ONLY_HIGH"}, 4, "BLOCK_ONLY_HIGH"},
+		{"off wins over specific settings", Config{SafetyOff: true, SafetySettings: map[string]string{"HARM_CATEGORY_HARASSMENT": "BLOCK_LOW_AND_ABOVE"}}, 4, "BLOCK_NONE"},
+		{"specific only", Config{SafetySettings: map[string]string{"HARM_CATEGORY_HARASSMENT": "BLOCK_NONE"}}, 1, "BLOCK_NONE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := geminiSafetySettings(&tt.cfg)
+			if len(settings) != tt.want {
+				t.Fatalf("got %d settings, want %d: %+v", len(settings), tt.want, settings)
+			}
+			for _, setting := range settings {
+				if setting.Threshold != tt.all {
+					t.Errorf("%s threshold = %q, want %q", setting.Category, setting.Threshold, tt.all)
+				}
+			}
+		})
+	}
+}
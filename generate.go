@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+var generateCmd = &cli.Command{
+	Name:    "generate",
+	Usage:   "Print a commit message for the staged changes",
+	Aliases: []string{"g"},
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "write the message to `FILE`, - for stdout",
+			Value: "-",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format: text, json with both a short and a long variant, or diff-only for the prompt that would be sent, without sending it",
+			Value: "text",
+		},
+		&cli.BoolFlag{
+			Name:  "commit",
+			Usage: "commit the staged changes with the generated message, skipping the editor",
+		},
+		&cli.BoolFlag{
+			Name:    "stage-all",
+			Aliases: []string{"a"},
+			Usage:   "with --commit, stage the modified and deleted tracked files first, like git commit -a",
+		},
+		&cli.BoolFlag{
+			Name:  "clipboard",
+			Usage: "copy the message to the clipboard instead of printing it",
+		},
+		&cli.BoolFlag{
+			Name:  "pr-description",
+			Usage: "also generate a Markdown pull request description",
+		},
+		&cli.StringFlag{
+			Name:  "pr-file",
+			Usage: "write the pull request description to `FILE`, - for stdout",
+			Value: "-",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if err := requireGit(); err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if cfg.Report {
+			defer report.print()
+		}
+
+		format := cmd.String("format")
+		switch format {
+		case "text", "json":
+		case "diff-only":
+			if cmd.Bool("commit") {
+				return fmt.Errorf("Error: --format diff-only doesn't make a message to commit")
+			}
+			return previewPrompt(cfg, cmd.String("output"))
+		default:
+			return fmt.Errorf("Error: Unknown format %q", format)
+		}
+
+		apiKey, source := resolveAPIKey(cfg)
+		if apiKey == "" {
+			return missingAPIKeyError(cfg)
+		}
+		if cfg.ValidateKey && !keyWorks(cfg, apiKey, source) {
+			return fmt.Errorf("Error: Invalid API key")
+		}
+
+		// Only the staged changes would be committed
+		if cmd.Bool("commit") && cfg.DiffTarget != diffTargetStaged {
+			return fmt.Errorf("Error: --commit only works with the staged changes")
+		}
+		if cmd.Bool("stage-all") {
+			if !cmd.Bool("commit") {
+				return fmt.Errorf("Error: --stage-all only works with --commit")
+			}
+			if err := stageTracked(); err != nil {
+				return err
+			}
+		}
+
+		diff, changedFiles, err := collectChanges(cfg, apiKey)
+		if err != nil {
+			return err
+		}
+		if diff == "" && cmd.Bool("stage-all") {
+			return fmt.Errorf("Error: No changes to tracked files, untracked files need git add")
+		}
+		if diff == "" && cfg.DiffTarget == diffTargetStaged {
+			return fmt.Errorf("Error: No staged changes")
+		}
+		if diff == "" {
+			return fmt.Errorf("Error: No changes")
+		}
+
+		standard := standardMessage(cfg, diff, changedFiles)
+
+		var message, output string
+		if format == "json" {
+			subject, _, _ := strings.Cut(standard, "\n")
+			variants := MessageVariants{Short: subject, Long: standard}
+			if standard == "" {
+				variants = generateCommitMessages(cfg, diff, changedFiles, apiKey, true)
+			}
+			message = variants.Long
+			if cfg.Variant == "short" {
+				message = variants.Short
+			}
+
+			encoded, err := json.MarshalIndent(struct {
+				Message string `json:"message"`
+				MessageVariants
+			}{message, variants}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("Failed to encode commit message: %w", err)
+			}
+			output = string(encoded)
+		} else {
+			message = standard
+			if message == "" {
+				message = generateCommitMessage(cfg, diff, changedFiles, apiKey)
+			}
+			output = message
+		}
+		if message == "" {
+			return fmt.Errorf("Error: No message generated")
+		}
+		if err := checkMessage(cfg, message); err != nil {
+			return err
+		}
+
+		if cmd.Bool("commit") {
+			if err := commitWithMessage(cfg, message); err != nil {
+				return err
+			}
+		}
+
+		copied := false
+		if cmd.Bool("clipboard") {
+			if err := copyToClipboard(cfg, output); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️ Couldn't copy the message to the clipboard, printing it instead: %s\n", err)
+			} else {
+				fmt.Fprintln(os.Stderr, "📋 Copied the message to the clipboard")
+				copied = true
+			}
+		}
+
+		// When committing or copying, the message only goes elsewhere if asked to
+		if (!cmd.Bool("commit") && !copied) || cmd.IsSet("output") {
+			if err := writeOutput(cmd.String("output"), output); err != nil {
+				return fmt.Errorf("Failed to write commit message: %w", err)
+			}
+		}
+
+		if !cmd.Bool("pr-description") {
+			return nil
+		}
+
+		description := generatePRDescription(cfg, diff, changedFiles, apiKey)
+		if description == "" {
+			return fmt.Errorf("Error: No pull request description generated")
+		}
+
+		// Keep the two apart when both end up on stdout
+		if cmd.String("output") == "-" && cmd.String("pr-file") == "-" {
+			fmt.Println()
+		}
+
+		if err := writeOutput(cmd.String("pr-file"), description); err != nil {
+			return fmt.Errorf("Failed to write pull request description: %w", err)
+		}
+
+		return nil
+	},
+}
+
+// previewPrompt writes the changed files and the diff exactly as they'd be
+// sent to the model, after all filtering, reductions and masking, without
+// making a request. No API key is needed, but one that's set is still kept
+// out of the preview.
+func previewPrompt(cfg *Config, outputPath string) error {
+	apiKey, _ := resolveAPIKey(cfg)
+	diff, changedFiles, err := collectChanges(cfg, apiKey)
+	if err != nil {
+		return err
+	}
+	if diff == "" && cfg.DiffTarget == diffTargetStaged {
+		return fmt.Errorf("Error: No staged changes")
+	}
+	if diff == "" {
+		return fmt.Errorf("Error: No changes")
+	}
+	if cfg.MapReduce && len(diff) > cfg.MapReduceThreshold {
+		fmt.Fprintln(os.Stderr, "⚠️ The diff would be summarized file by file first, which needs the model, showing it whole")
+	}
+
+	prompt, _, _ := messagePrompt(cfg, diff, changedFiles, cfg.Variant != "")
+	if err := writeOutput(outputPath, strings.TrimSpace(prompt)); err != nil {
+		return fmt.Errorf("Failed to write prompt: %w", err)
+	}
+
+	return nil
+}
+
+// stageTracked stages the modifications and deletions of tracked files,
+// leaving untracked files out as git commit -a does.
+func stageTracked() error {
+	gitCmd := exec.Command("git", "add", "--update")
+	gitCmd.Stderr = os.Stderr
+	if err := gitCmd.Run(); err != nil {
+		return fmt.Errorf("Failed to stage the changes: %w", err)
+	}
+
+	return nil
+}
+
+// commitWithMessage creates the commit from the staged changes, asking for
+// confirmation first in interactive mode.
+func commitWithMessage(cfg *Config, message string) error {
+	if cfg.Interactive {
+		fmt.Fprintf(os.Stderr, "\n%s\n\n", message)
+		if !confirm("Commit with this message?") {
+			return fmt.Errorf("Error: Commit cancelled")
+		}
+	}
+
+	gitCmd := exec.Command("git", "commit", "-F", "-")
+	gitCmd.Stdin = strings.NewReader(message + "\n")
+	gitCmd.Stdout = os.Stderr
+	gitCmd.Stderr = os.Stderr
+	if err := gitCmd.Run(); err != nil {
+		return fmt.Errorf("Failed to commit: %w", err)
+	}
+
+	return nil
+}
+
+// writeOutput writes content to the given path followed by a newline,
+// creating parent directories as needed. The path "-" means stdout.
+func writeOutput(path, content string) error {
+	if path == "-" || path == "" {
+		fmt.Println(content)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Written to %s\n", path)
+	return nil
+}
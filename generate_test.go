@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewPromptMatchesRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"partially staged", nil, "Only some changes of these files are staged"},
+		{"language", []string{"--language", "Polish"}, "Write the commit message in Polish."},
+		{"ascii", []string{"--ascii-only"}, asciiInstruction},
+		{"variant", []string{"--variant", "short"}, variantsInstruction},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			initRepo(t)
+			commitFile(t, "main.go", "package main\n\nfunc a() {}\n\nfunc b() {}\n", "feat: add main")
+			writeFile(t, "main.go", "package main\n\nfunc a() { println() }\n\nfunc b() {}\n")
+			runGit(t, "add", "main.go")
+			writeFile(t, "main.go", "package main\n\nfunc a() { println() }\n\nfunc b() { println() }\n")
+
+			cfg, err := loadTestConfig(t, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(t.TempDir(), "prompt")
+			if err := previewPrompt(cfg, output); err != nil {
+				t.Fatal(err)
+			}
+			preview, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(preview), tt.want) {
+				t.Errorf("preview lacks %q:\n%s", tt.want, preview)
+			}
+
+			// The real request, from a fresh config
+			cfg, err = loadTestConfig(t, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			requests := fakeOpenAI(t, cfg, textResponse("feat: print", "stop"))
+			diff, files, err := collectChanges(cfg, "key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			generateCommitMessage(cfg, diff, files, "key")
+			if len(*requests) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(*requests))
+			}
+			if sent := (*requests)[0].Messages[1].Content; strings.TrimSpace(sent) != strings.TrimSpace(string(preview)) {
+				t.Errorf("preview:\n%s\n\nsent:\n%s", preview, sent)
+			}
+		})
+	}
+}
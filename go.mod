@@ -2,4 +2,4 @@ module github.com/bart-jaskulski/commitment
 
 go 1.23.2
 
-require github.com/urfave/cli/v3 v3.0.0-beta1 // indirect
+require github.com/urfave/cli/v3 v3.0.0-beta1
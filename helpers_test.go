@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+// isolate points the config and cache directories at a fresh temporary
+// directory and runs the test from an empty one outside any repository. It
+// returns the path of the global config file.
+func isolate(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, name := range []string{"GEMINI_API_KEY", "COMMITMENT_API_KEY", "GIT_AUTHOR_DATE", "GIT_EDITOR"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	chdir(t, t.TempDir())
+
+	return filepath.Join(home, "config", "commitment", "config.json")
+}
+
+// chdir changes the working directory for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+}
+
+// writeFile writes the file, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// initRepo creates an empty repository and moves into it.
+func initRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	runGit(t, "init", "-q", "-b", "main")
+	runGit(t, "config", "user.name", "Test")
+	runGit(t, "config", "user.email", "test@example.com")
+	runGit(t, "config", "commit.gpgsign", "false")
+
+	return dir
+}
+
+// runGit runs git in the working directory and returns its output.
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, output)
+	}
+
+	return string(output)
+}
+
+// commitFile writes the file and commits it with the message.
+func commitFile(t *testing.T, path, content, message string) {
+	t.Helper()
+
+	writeFile(t, path, content)
+	runGit(t, "add", path)
+	runGit(t, "commit", "-q", "-m", message)
+}
+
+// freshFlags copies the root command's flags, which keep their values once
+// parsed, so each run gets untouched ones.
+func freshFlags() []cli.Flag {
+	flags := make([]cli.Flag, 0, len(rootCmd.Flags))
+	for _, flag := range rootCmd.Flags {
+		original := reflect.ValueOf(flag).Elem()
+		fresh := reflect.New(original.Type())
+		fresh.Elem().Set(original)
+		flags = append(flags, fresh.Interface().(cli.Flag))
+	}
+
+	return flags
+}
+
+// loadTestConfig loads the config as the root command would with the flags.
+func loadTestConfig(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+
+	var cfg *Config
+	var loadErr error
+	cmd := &cli.Command{
+		Name:  "commitment",
+		Flags: freshFlags(),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			cfg, loadErr = loadConfig(cmd)
+			return nil
+		},
+	}
+	if err := cmd.Run(context.Background(), append([]string{"commitment"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg, loadErr
+}
+
+// runRoot runs the root command, as the hook does, with the arguments.
+func runRoot(t *testing.T, args ...string) error {
+	t.Helper()
+
+	cmd := &cli.Command{Name: "commitment", Flags: freshFlags(), Action: rootCmd.Action}
+	return cmd.Run(context.Background(), append([]string{"commitment"}, args...))
+}
+
+// captureStderr returns what the function writes to stderr.
+func captureStderr(t *testing.T, run func()) string {
+	t.Helper()
+
+	return capture(t, &os.Stderr, run)
+}
+
+// captureStdout returns what the function writes to stdout.
+func captureStdout(t *testing.T, run func()) string {
+	t.Helper()
+
+	return capture(t, &os.Stdout, run)
+}
+
+// capture redirects the stream to a file while run runs and returns what
+// was written to it.
+func capture(t *testing.T, stream **os.File, run func()) string {
+	t.Helper()
+
+	file, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	original := *stream
+	*stream = file
+	defer func() { *stream = original }()
+	run()
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// changedPaths extracts file paths from `git diff --name-status` output. For
+// renames and copies the destination path is used.
+func changedPaths(files string) []string {
+	paths := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(files), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		paths = append(paths, fields[len(fields)-1])
+	}
+
+	return paths
+}
+
+// matchPromptHints returns the hints whose pattern matches at least one of
+// the paths, each hint appearing once and in config order.
+func matchPromptHints(hints []PromptHint, paths []string) []string {
+	matched := []string{}
+	seen := map[string]bool{}
+	for _, hint := range hints {
+		if seen[hint.Hint] {
+			continue
+		}
+
+		for _, path := range paths {
+			if hintMatches(hint.Match, path) {
+				matched = append(matched, hint.Hint)
+				seen[hint.Hint] = true
+				break
+			}
+		}
+	}
+
+	return matched
+}
+
+// sensitivePath returns the first path matching one of the sensitive
+// patterns, or an empty string when there is none.
+func sensitivePath(patterns, paths []string) string {
+	for _, path := range paths {
+		for _, pattern := range patterns {
+			if hintMatches(pattern, path) {
+				return path
+			}
+		}
+	}
+
+	return ""
+}
+
+func hintMatches(pattern, path string) bool {
+	if ok, _ := filepath.Match(pattern, path); ok {
+		return true
+	}
+	if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+		return true
+	}
+
+	// A trailing slash matches everything below that directory
+	return strings.HasSuffix(pattern, "/") && strings.HasPrefix(path, pattern)
+}
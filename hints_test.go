@@ -0,0 +1,65 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChangedPaths(t *testing.T) {
+	files := "M\tmain.go\nA\tdb/migrations/001_init.sql\nR100\told.go\tnew.go\nC075\ta.go\tb.go\n"
+	want := []string{"main.go", "db/migrations/001_init.sql", "new.go", "b.go"}
+	if got := changedPaths(files); !slices.Equal(got, want) {
+		t.Errorf("changedPaths() = %q, want %q", got, want)
+	}
+	if got := changedPaths(""); len(got) != 0 {
+		t.Errorf("changedPaths(\"\") = %q, want none", got)
+	}
+}
+
+func TestMatchPromptHints(t *testing.T) {
+	hints := []PromptHint{
+		{Match: "*.sql", Hint: "this includes a DB migration; mention schema changes"},
+		{Match: "Dockerfile", Hint: "this changes the container image"},
+		{Match: "*_test.go", Hint: "this changes tests"},
+		{Match: "docs/", Hint: "this changes the documentation"},
+		{Match: "*.md", Hint: "this changes the documentation"},
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{"none", []string{"main.go"}, []string{}},
+		{"extension in a directory", []string{"db/migrations/001_init.sql"}, []string{hints[0].Hint}},
+		{"base name", []string{"build/Dockerfile"}, []string{hints[1].Hint}},
+		{"directory", []string{"docs/guide/setup.txt"}, []string{hints[3].Hint}},
+		{"mixed, in config order", []string{"main_test.go", "Dockerfile", "schema.sql"}, []string{hints[0].Hint, hints[1].Hint, hints[2].Hint}},
+		{"the same hint once", []string{"docs/index.md", "README.md"}, []string{hints[3].Hint}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPromptHints(hints, tt.paths); !slices.Equal(got, tt.want) {
+				t.Errorf("matchPromptHints() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildUserPromptHints(t *testing.T) {
+	cfg := &Config{Hints: []PromptHint{
+		{Match: "*.sql", Hint: "this includes a DB migration"},
+		{Match: "Dockerfile", Hint: "this changes the container image"},
+	}}
+
+	prompt := buildUserPrompt(cfg, "diff --git a/schema.sql b/schema.sql\n", "M\tschema.sql\nM\tDockerfile")
+	if !strings.Contains(prompt, "Keep in mind:\n- this includes a DB migration\n- this changes the container image") {
+		t.Errorf("prompt lacks the hints:\n%s", prompt)
+	}
+
+	prompt = buildUserPrompt(cfg, "diff --git a/main.go b/main.go\n", "M\tmain.go")
+	if strings.Contains(prompt, "Keep in mind") {
+		t.Errorf("prompt has hints for no matching file:\n%s", prompt)
+	}
+}
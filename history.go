@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// historyWindow bounds the commits the style examples are sampled from, so
+// they stay relevant while history is being rewritten.
+type historyWindow struct {
+	// Base is the commit being described, only the history before it is
+	// sampled. Empty samples HEAD's history.
+	Base string
+
+	// Until is the latest author time, in Unix seconds, of a sampled commit.
+	// Zero doesn't limit it.
+	Until int64
+}
+
+// currentHistoryWindow positions the window for this run: before the commit
+// being backfilled, and before the author date git sets while rebasing.
+func currentHistoryWindow(cfg *Config) historyWindow {
+	window := historyWindow{Base: cfg.HistoryBase}
+	if date := os.Getenv("GIT_AUTHOR_DATE"); date != "" {
+		window.Until = parseGitDate(date)
+	}
+
+	return window
+}
+
+// revision is where git log starts walking.
+func (w historyWindow) revision() string {
+	if w.Base != "" {
+		return w.Base + "^"
+	}
+
+	return "HEAD"
+}
+
+// logArgs limits git log to the window, so a count it's given counts only
+// commits within it. git log bounds the commit date, which is never before the
+// author date, a commit it keeps can still be authored after the window ends.
+func (w historyWindow) logArgs() []string {
+	args := []string{w.revision()}
+	if w.Until != 0 {
+		args = append(args, "--until=@"+strconv.FormatInt(w.Until, 10))
+	}
+
+	return args
+}
+
+// includes reports whether a commit authored at the given time is within the
+// window.
+func (w historyWindow) includes(authorTime int64) bool {
+	return w.Until == 0 || authorTime <= w.Until
+}
+
+// parseGitDate converts any date git understands to Unix seconds, or zero
+// when git doesn't.
+func parseGitDate(date string) int64 {
+	output, err := exec.Command("git", "rev-parse", "--until="+date).Output()
+	if err != nil {
+		return 0
+	}
+
+	seconds, found := strings.CutPrefix(strings.TrimSpace(string(output)), "--min-age=")
+	if !found {
+		return 0
+	}
+	parsed, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return parsed
+}
+
+// revisionExists reports whether the revision names a commit.
+func revisionExists(revision string) bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", revision+"^{commit}").Run() == nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestGetAuthorRecentCommitsWindow(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	// Older commits within the window, then more than git log is asked for
+	// after it
+	for i := range 25 {
+		date := fmt.Sprintf("@%d +0000", 1700000000+i*3600)
+		t.Setenv("GIT_AUTHOR_DATE", date)
+		t.Setenv("GIT_COMMITTER_DATE", date)
+		commitFile(t, "file", fmt.Sprint(i), fmt.Sprintf("feat: change %d\nBody %d.", i, i))
+	}
+
+	tests := []struct {
+		name   string
+		window historyWindow
+		want   []string
+	}{
+		{"no limit", historyWindow{}, []string{"feat: change 24\nBody 24.", "feat: change 23\nBody 23."}},
+		{"until", historyWindow{Until: 1700000000 + 2*3600}, []string{"feat: change 2\nBody 2.", "feat: change 1\nBody 1."}},
+		{"before the first", historyWindow{Until: 1600000000}, nil},
+		{"base and until", historyWindow{Base: "HEAD~20", Until: 1700000000 + 10*3600}, []string{"feat: change 3\nBody 3.", "feat: change 2\nBody 2."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getAuthorRecentCommits(tt.window, "*", 2)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("getAuthorRecentCommits() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
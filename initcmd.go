@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+)
+
+//go:embed starter_config
+var starterConfig string
+
+var initCmd = &cli.Command{
+	Name:  "init",
+	Usage: "Write a commented starter config with every setting at its default",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "local",
+			Usage: "write the repository config instead of the global one",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "overwrite an existing config",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		var path string
+		if cmd.Bool("local") {
+			if err := requireGit(); err != nil {
+				return err
+			}
+			root := getRepoRoot()
+			if root == "" {
+				return fmt.Errorf("Error: Not inside a git repository")
+			}
+			path = filepath.Join(root, ".commitment", "config.json")
+		} else {
+			dir, err := os.UserConfigDir()
+			if err != nil {
+				return fmt.Errorf("Failed to find the config directory: %w", err)
+			}
+			path = filepath.Join(dir, "commitment", "config.json")
+		}
+
+		if _, err := os.Stat(path); err == nil && !cmd.Bool("force") {
+			return fmt.Errorf("Error: %s already exists, use --force to overwrite it", path)
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("Failed to check %s: %w", path, err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("Failed to create config directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(starterConfig), 0644); err != nil {
+			return fmt.Errorf("Failed to write config: %w", err)
+		}
+
+		fmt.Printf("✅ Starter config written to %s\n", path)
+		return nil
+	},
+}
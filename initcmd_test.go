@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestStarterConfigPostProcessors(t *testing.T) {
+	match := regexp.MustCompile(`(?m)^\s*// "post_processors": (\[.*\]),$`).FindStringSubmatch(starterConfig)
+	if match == nil {
+		t.Fatal("no post_processors example in the starter config")
+	}
+
+	var listed []string
+	if err := json.Unmarshal([]byte(match[1]), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(listed, defaultPostProcessors) {
+		t.Errorf("starter config lists %q, the default is %q", listed, defaultPostProcessors)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// confirm asks a yes/no question on the terminal. Git hooks don't get the
+// terminal as stdin, so the controlling terminal is read directly.
+func confirm(question string) bool {
+	answer := strings.ToLower(ask(question + " [y/N]"))
+	return answer == "y" || answer == "yes"
+}
+
+// choose asks for one of count numbered options and returns its index, the
+// first one unless a valid number is entered.
+func choose(question string, count int) int {
+	choice, err := strconv.Atoi(ask(fmt.Sprintf("%s [1-%d, default 1]", question, count)))
+	if err != nil || choice < 1 || choice > count {
+		return 0
+	}
+
+	return choice - 1
+}
+
+// ask reads an answer to the question from the terminal.
+func ask(question string) string {
+	input := os.Stdin
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		input = tty
+	}
+
+	fmt.Fprintf(os.Stderr, "❓ %s ", question)
+	answer, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(answer)
+}
+
+// runPostCommand runs the configured shell command with the commit message
+// file path as its last argument, e.g. to lint the generated message.
+func runPostCommand(command, commitMsgFile string) error {
+	cmd := exec.Command("sh", "-c", command+` "$1"`, "sh", commitMsgFile)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Post command failed: %s\n", err)
+		return err
+	}
+
+	return nil
+}
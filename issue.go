@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	reBranchIssue = regexp.MustCompile(`(?:^|[/_-])(\d+)(?:[/_-]|$)`)
+	// An unmistakable issue reference, like "#12", "issue-12" or "gh-12"
+	reBranchIssueExplicit = regexp.MustCompile(`(?i)(?:#|(?:^|[/_-])(?:issues?|gh)[/_-]?)(\d+)(?:[/_-]|$)`)
+	// A Jira style key, like "ABC-12", whose number is no issue of the forge
+	reTicketKey = regexp.MustCompile(`\b[A-Z][A-Z0-9]*-\d+\b`)
+	reRemoteURL = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^:/]+)(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+)
+
+// issueFetchTimeout keeps a slow forge from holding up the commit.
+const issueFetchTimeout = 5 * time.Second
+
+// branchIssueNumber finds an issue number in a branch name like
+// "feature/123-login", "fix-123" or "ABC-12-issue-34". An explicit reference
+// like "#34" or "issue-34" wins, and the numbers of Jira style keys like
+// "ABC-12" are never taken for one.
+func branchIssueNumber(branch string) string {
+	if matches := reBranchIssueExplicit.FindStringSubmatch(branch); matches != nil {
+		return matches[1]
+	}
+
+	matches := reBranchIssue.FindStringSubmatch(reTicketKey.ReplaceAllString(branch, ""))
+	if matches == nil {
+		return ""
+	}
+
+	return matches[1]
+}
+
+// parseRemoteURL splits an SSH or HTTPS remote into its host and project
+// path, e.g. "github.com" and "owner/repo".
+func parseRemoteURL(remote string) (string, string, bool) {
+	matches := reRemoteURL.FindStringSubmatch(strings.TrimSpace(remote))
+	if matches == nil {
+		return "", "", false
+	}
+
+	return matches[1], matches[2], true
+}
+
+func getCurrentBranch() string {
+	cmd := exec.Command("git", "symbolic-ref", "--short", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+func getOriginURL() string {
+	cmd := exec.Command("git", "remote", "get-url", "origin")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// fetchIssueTitle looks up the title of the issue referenced by the current
+// branch on the forge behind the origin remote. Any failure just means no
+// extra context.
+func fetchIssueTitle() string {
+	number := branchIssueNumber(getCurrentBranch())
+	if number == "" {
+		return ""
+	}
+
+	host, project, ok := parseRemoteURL(getOriginURL())
+	if !ok {
+		return ""
+	}
+
+	var req *http.Request
+	var err error
+	switch {
+	case host == "github.com":
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			token = os.Getenv("GH_TOKEN")
+		}
+		if token == "" {
+			return ""
+		}
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/issues/%s", project, number), nil)
+		if err != nil {
+			return ""
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+	case strings.Contains(host, "gitlab"):
+		token := os.Getenv("GITLAB_TOKEN")
+		if token == "" {
+			return ""
+		}
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("https://%s/api/v4/projects/%s/issues/%s", host, url.PathEscape(project), number), nil)
+		if err != nil {
+			return ""
+		}
+		req.Header.Set("PRIVATE-TOKEN", token)
+	default:
+		return ""
+	}
+	req.Header.Set("User-Agent", userAgent())
+
+	client := &http.Client{Timeout: issueFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't fetch issue #%s, skipping issue context: %s\n", number, err)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't fetch issue #%s (status %d), skipping issue context\n", number, resp.StatusCode)
+		return ""
+	}
+
+	var issue struct {
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(issue.Title)
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestBranchIssueNumber(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"feature/123-login", "123"},
+		{"fix-123", "123"},
+		{"123", "123"},
+		{"gh-45", "45"},
+		{"fix/#78-crash", "78"},
+		{"fix/issue-90", "90"},
+		{"issues/91-typo", "91"},
+		{"ABC-12-issue-34", "34"},
+		{"ABC-12/#34", "34"},
+		{"feature/ABC-12-login", ""},
+		{"ABC-12", ""},
+		{"PROJ2-7-fix-88", "88"},
+		{"main", ""},
+		{"release/v2", ""},
+		{"feature/oauth2-login", ""},
+	}
+	for _, tt := range tests {
+		if got := branchIssueNumber(tt.branch); got != tt.want {
+			t.Errorf("branchIssueNumber(%q) = %q, want %q", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote  string
+		host    string
+		project string
+	}{
+		{"git@github.com:owner/repo.git", "github.com", "owner/repo"},
+		{"https://github.com/owner/repo", "github.com", "owner/repo"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/repo.git", "gitlab.example.com", "group/sub/repo"},
+	}
+	for _, tt := range tests {
+		host, project, ok := parseRemoteURL(tt.remote)
+		if !ok || host != tt.host || project != tt.project {
+			t.Errorf("parseRemoteURL(%q) = %q, %q, %v", tt.remote, host, project, ok)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// keyCheckTimeout keeps the preflight from holding up the commit.
+	keyCheckTimeout = 10 * time.Second
+
+	// keyCheckTTL is how long a working key isn't checked again.
+	keyCheckTTL = time.Hour
+)
+
+var geminiNativeModelsEndpoint = "https://generativelanguage.googleapis.com/v1beta/models?pageSize=1"
+
+// keyWorks checks the API key with a cheap request listing the models before
+// any real generation, so a bad key gets a clear message instead of an API
+// error. Only a rejected key fails the check, a network problem is reported
+// and left to the real request. A working key is remembered for a while.
+func keyWorks(cfg *Config, apiKey, source string) bool {
+	marker := keyCheckMarker(cfg, apiKey)
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < keyCheckTTL {
+		logVerbose(cfg, "✅ API key checked %s ago", time.Since(info.ModTime()).Round(time.Second))
+		return true
+	}
+
+	var req *http.Request
+	var err error
+	switch cfg.Provider {
+	case providerGeminiNative:
+		req, err = http.NewRequest("GET", geminiNativeModelsEndpoint, nil)
+		if err == nil {
+			req.Header.Set("x-goog-api-key", apiKey)
+		}
+	default:
+		req, err = http.NewRequest("GET", cfg.baseURL()+"/models", nil)
+		if err == nil {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't check the API key: %s\n", err)
+		return true
+	}
+	req.Header.Set("User-Agent", userAgent())
+
+	waitForRequest(cfg)
+	client := &http.Client{Timeout: keyCheckTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		debugLog(cfg, apiKey, req, 0, []byte(err.Error()))
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't check the API key: %s\n", err)
+		return true
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	debugLog(cfg, apiKey, req, resp.StatusCode, body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		fmt.Fprintln(os.Stderr, "✅ API key works")
+		if marker != "" && os.MkdirAll(filepath.Dir(marker), 0755) == nil {
+			_ = os.WriteFile(marker, nil, 0644)
+		}
+		return true
+	// Gemini rejects bad keys with a 400 rather than a 401, elsewhere a 400
+	// says nothing about the key
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
+		resp.StatusCode == http.StatusBadRequest && isGeminiEndpoint(cfg):
+		fmt.Fprintf(os.Stderr, "❌ Your API key from %s is invalid (status %d)\n", source, resp.StatusCode)
+		return false
+	default:
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't check the API key (status %d)\n", resp.StatusCode)
+		return true
+	}
+}
+
+// isGeminiEndpoint reports whether the requests go to Gemini, natively or
+// through its OpenAI-compatible API.
+func isGeminiEndpoint(cfg *Config) bool {
+	if cfg.Provider == providerGeminiNative {
+		return true
+	}
+
+	endpoint, err := url.Parse(cfg.baseURL())
+	return err == nil && endpoint.Host == "generativelanguage.googleapis.com"
+}
+
+// keyCheckMarker is the file whose age tells when the key last worked. It's
+// named after a hash of the provider and key, never the key itself.
+func keyCheckMarker(cfg *Config, apiKey string) string {
+	sum := sha256.Sum256([]byte(cfg.Provider + "\x00" + apiKey))
+	path, err := cachePath("key-" + hex.EncodeToString(sum[:]))
+	if err != nil {
+		return ""
+	}
+
+	return path
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeModels answers the models listing of both providers with the status,
+// under a temporary "gateway" preset for the OpenAI-compatible one.
+func fakeModels(t *testing.T, status int) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	providerPresets["gateway"] = ProviderPreset{BaseURL: server.URL, Features: allFeatures}
+	t.Cleanup(func() { delete(providerPresets, "gateway") })
+	endpoint := geminiNativeModelsEndpoint
+	geminiNativeModelsEndpoint = server.URL + "/v1beta/models?pageSize=1"
+	t.Cleanup(func() { geminiNativeModelsEndpoint = endpoint })
+}
+
+func TestKeyWorks(t *testing.T) {
+	tests := []struct {
+		provider string
+		status   int
+		want     bool
+		stderr   string
+	}{
+		{"gateway", http.StatusOK, true, "API key works"},
+		{"gateway", http.StatusUnauthorized, false, "is invalid (status 401)"},
+		{"gateway", http.StatusForbidden, false, "is invalid (status 403)"},
+		{"gateway", http.StatusBadRequest, true, "Couldn't check the API key (status 400)"},
+		{"gateway", http.StatusNotFound, true, "Couldn't check the API key (status 404)"},
+		{providerGeminiNative, http.StatusBadRequest, false, "is invalid (status 400)"},
+		{providerGeminiNative, http.StatusInternalServerError, true, "Couldn't check the API key (status 500)"},
+	}
+	for _, tt := range tests {
+		isolate(t)
+		fakeModels(t, tt.status)
+
+		var got bool
+		stderr := captureStderr(t, func() { got = keyWorks(&Config{Provider: tt.provider}, "key", "env KEY") })
+		if got != tt.want {
+			t.Errorf("%s answering %d: keyWorks() = %v, want %v", tt.provider, tt.status, got, tt.want)
+		}
+		if !strings.Contains(stderr, tt.stderr) {
+			t.Errorf("%s answering %d: stderr = %q, want %q", tt.provider, tt.status, stderr, tt.stderr)
+		}
+	}
+}
+
+func TestIsGeminiEndpoint(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     bool
+	}{
+		{providerOpenAI, true},
+		{providerGeminiNative, true},
+		{"groq", false},
+		{"mistral", false},
+	}
+	for _, tt := range tests {
+		if got := isGeminiEndpoint(&Config{Provider: tt.provider}); got != tt.want {
+			t.Errorf("isGeminiEndpoint(%s) = %v, want %v", tt.provider, got, tt.want)
+		}
+	}
+}
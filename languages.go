@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// languageExtensions maps file extensions to the language they're written in,
+// lower case and with the leading dot.
+var languageExtensions = map[string]string{
+	".go": "Go", ".rs": "Rust", ".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++",
+	".java": "Java", ".kt": "Kotlin", ".scala": "Scala", ".swift": "Swift", ".m": "Objective-C",
+	".cs": "C#", ".fs": "F#", ".py": "Python", ".rb": "Ruby", ".php": "PHP", ".pl": "Perl",
+	".lua": "Lua", ".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang", ".hs": "Haskell",
+	".clj": "Clojure", ".dart": "Dart", ".zig": "Zig",
+	".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript", ".jsx": "JavaScript",
+	".ts": "TypeScript", ".mts": "TypeScript", ".tsx": "TypeScript", ".vue": "Vue", ".svelte": "Svelte",
+	".html": "HTML", ".css": "CSS", ".scss": "SCSS", ".sass": "Sass", ".less": "Less",
+	".sql": "SQL", ".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".fish": "Shell", ".ps1": "PowerShell",
+	".md": "Markdown", ".rst": "reStructuredText", ".tf": "Terraform", ".proto": "Protocol Buffers",
+	".graphql": "GraphQL", ".yml": "YAML", ".yaml": "YAML", ".toml": "TOML", ".json": "JSON", ".xml": "XML",
+}
+
+// languageFiles maps well-known file names without a telling extension.
+var languageFiles = map[string]string{
+	"Dockerfile": "Dockerfile", "Makefile": "Makefile", "go.mod": "Go modules", "Gemfile": "Ruby",
+	"CMakeLists.txt": "CMake", "Jenkinsfile": "Groovy",
+}
+
+// detectLanguages summarizes the languages of the changed files, the most
+// touched first, e.g. "Go (3 files), SQL (1 file)". Unknown files are left
+// out.
+func detectLanguages(paths []string) string {
+	counts := map[string]int{}
+	for _, path := range paths {
+		language, ok := languageFiles[filepath.Base(path)]
+		if !ok {
+			language, ok = languageExtensions[strings.ToLower(filepath.Ext(path))]
+		}
+		if ok {
+			counts[language]++
+		}
+	}
+
+	languages := make([]string, 0, len(counts))
+	for language := range counts {
+		languages = append(languages, language)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if counts[languages[i]] != counts[languages[j]] {
+			return counts[languages[i]] > counts[languages[j]]
+		}
+		return languages[i] < languages[j]
+	})
+
+	summary := make([]string, 0, len(languages))
+	for _, language := range languages {
+		unit := "files"
+		if counts[language] == 1 {
+			unit = "file"
+		}
+		summary = append(summary, fmt.Sprintf("%s (%d %s)", language, counts[language], unit))
+	}
+
+	return strings.Join(summary, ", ")
+}
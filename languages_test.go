@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguages(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"unknown files", []string{"LICENSE", "data.bin"}, ""},
+		{"one", []string{"main.go"}, "Go (1 file)"},
+		{"most touched first", []string{"db/001.sql", "main.go", "config.go", "web/app.TS"}, "Go (2 files), SQL (1 file), TypeScript (1 file)"},
+		{"ties by name", []string{"b.py", "a.rb"}, "Python (1 file), Ruby (1 file)"},
+		{"well-known names", []string{"Dockerfile", "build/Makefile", "go.mod", "go.sum"}, "Dockerfile (1 file), Go modules (1 file), Makefile (1 file)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguages(tt.paths); got != tt.want {
+				t.Errorf("detectLanguages() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildUserPromptLanguages(t *testing.T) {
+	files := "M\tmain.go\nA\tschema.sql"
+	diff := "diff --git a/main.go b/main.go\n"
+
+	if prompt := buildUserPrompt(&Config{}, diff, files); strings.Contains(prompt, "Go (1 file)") {
+		t.Errorf("languages in the prompt without detect_languages:\n%s", prompt)
+	}
+	if prompt := buildUserPrompt(&Config{DetectLanguages: true}, diff, files); !strings.Contains(prompt, "Go (1 file), SQL (1 file)") {
+		t.Errorf("prompt lacks the languages:\n%s", prompt)
+	}
+}
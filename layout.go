@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// defaultOutputTemplate lays out the message the way the model wrote it, a
+// starting point for your own.
+const defaultOutputTemplate = "{{.Subject}}{{if .Body}}\n\n{{.Body}}{{end}}{{if .Trailers}}\n\n{{.Trailers}}{{end}}"
+
+// MessageFields are the parts of a generated message an output template
+// lays out.
+type MessageFields struct {
+	Subject  string
+	Body     string
+	Trailers string
+	Ticket   string
+	Branch   string
+}
+
+// parseMessageFields splits a message into its subject, body and the trailer
+// block at its end.
+func parseMessageFields(message string) MessageFields {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	fields := MessageFields{Subject: strings.TrimSpace(subject)}
+
+	paragraphs := strings.Split(strings.TrimSpace(body), "\n\n")
+	if last := paragraphs[len(paragraphs)-1]; last != "" && isTrailerBlock(last) {
+		fields.Trailers = last
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+	fields.Body = strings.TrimSpace(strings.Join(paragraphs, "\n\n"))
+
+	return fields
+}
+
+// renderOutputTemplate lays out the message with the configured template,
+// keeping it as it is when the template fails.
+func renderOutputTemplate(cfg *Config, message string) string {
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(cfg.OutputTemplate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Invalid output template, keeping the message as it is: %s\n", err)
+		return message
+	}
+
+	fields := parseMessageFields(message)
+	fields.Branch = getCurrentBranch()
+	if cfg.Skeleton != nil {
+		fields.Ticket = cfg.Skeleton.ticket(fields.Branch)
+	}
+
+	var output strings.Builder
+	if err := tmpl.Execute(&output, fields); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Output template failed, keeping the message as it is: %s\n", err)
+		return message
+	}
+
+	return strings.TrimSpace(output.String())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMessageFields(t *testing.T) {
+	tests := []struct {
+		message string
+		want    MessageFields
+	}{
+		{"", MessageFields{}},
+		{"fix: typo\n", MessageFields{Subject: "fix: typo"}},
+		{"fix: typo\n\nWhy.\n\nMore why.", MessageFields{Subject: "fix: typo", Body: "Why.\n\nMore why."}},
+		{"fix: typo\n\nWhy.\n\nRefs: #1\nSigned-off-by: A <a@example.com>", MessageFields{Subject: "fix: typo", Body: "Why.", Trailers: "Refs: #1\nSigned-off-by: A <a@example.com>"}},
+		{"fix: typo\n\nCloses #3", MessageFields{Subject: "fix: typo", Trailers: "Closes #3"}},
+		{"fix: typo\n\nNote: this is prose, not a trailer.\n\nWhy.", MessageFields{Subject: "fix: typo", Body: "Note: this is prose, not a trailer.\n\nWhy."}},
+	}
+	for _, tt := range tests {
+		if got := parseMessageFields(tt.message); got != tt.want {
+			t.Errorf("parseMessageFields(%q) = %+v, want %+v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestRenderOutputTemplate(t *testing.T) {
+	message := "fix: typo\n\nWhy.\n\nRefs: #1"
+	tests := []struct {
+		name     string
+		template string
+		message  string
+		want     string
+		warning  string
+	}{
+		{"default", defaultOutputTemplate, message, message, ""},
+		{"default without a body", defaultOutputTemplate, "fix: typo\n\nRefs: #1", "fix: typo\n\nRefs: #1", ""},
+		{"ticket and separator", "{{.Ticket}} {{.Subject}}\n---\n{{.Body}}\n---\n{{.Trailers}}", message, "ABC-12 fix: typo\n---\nWhy.\n---\nRefs: #1", ""},
+		{"branch", "{{.Subject}}\n\nBranch: {{.Branch}}", message, "fix: typo\n\nBranch: ABC-12-typo", ""},
+		{"unknown field", "{{.Summary}}", message, message, "Output template failed"},
+		{"invalid", "{{.Subject", message, message, "Invalid output template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			initRepo(t)
+			runGit(t, "checkout", "-q", "-b", "ABC-12-typo")
+
+			cfg := &Config{OutputTemplate: tt.template, Skeleton: &Skeleton{TicketPattern: `[A-Z]+-\d+`}}
+			var got string
+			stderr := captureStderr(t, func() { got = renderOutputTemplate(cfg, tt.message) })
+			if got != tt.want {
+				t.Errorf("renderOutputTemplate() = %q, want %q", got, tt.want)
+			}
+			if tt.warning == "" && stderr != "" || !strings.Contains(stderr, tt.warning) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.warning)
+			}
+		})
+	}
+}
+
+func TestOutputTemplateConfig(t *testing.T) {
+	path := isolate(t)
+	cfg, err := loadTestConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Off unless set, the message is kept the way the model wrote it
+	if cfg.OutputTemplate != "" {
+		t.Errorf("default output_template = %q", cfg.OutputTemplate)
+	}
+
+	writeFile(t, path, `{"output_template": "{{.Subject"}`)
+	if _, err := loadTestConfig(t); err == nil || !strings.Contains(err.Error(), "invalid output template") {
+		t.Errorf("invalid output_template: %v", err)
+	}
+}
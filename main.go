@@ -4,15 +4,15 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -23,89 +23,590 @@ import (
 var systemPrompt string
 
 const (
-	maxTokens   = 120
-	apiEndpoint = "https://generativelanguage.googleapis.com/v1beta/openai/chat/completions"
-	model       = "gemini-2.0-flash"
+	maxTokens    = 120
+	apiBaseURL   = "https://generativelanguage.googleapis.com/v1beta/openai"
+	defaultModel = "gemini-2.0-flash"
 )
 
-type OpenAIRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens"`
-	Temperature float64   `json:"temperature"`
-}
-
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
+// The route of the chat completions API under the base URL, which gateways
+// may move with api_path and api_method.
+const (
+	defaultAPIPath   = "/chat/completions"
+	defaultAPIMethod = "POST"
+)
 
-type OpenAIResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-}
+// lowTokenBudget is about the least a full subject line takes.
+const lowTokenBudget = 40
 
 var rootCmd = &cli.Command{
-	Name:  "commitment",
-	Usage: "Generate commit messages and install git hooks",
+	Name:      "commitment",
+	Usage:     "Generate commit messages and install git hooks",
+	ArgsUsage: "[commit-msg-file [commit-source]]",
+	// Adds the `completion` command printing bash, zsh, fish and pwsh scripts
+	EnableShellCompletion: true,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "profile",
+			Usage: "apply the named settings profile from the config",
+		},
+		&cli.BoolFlag{
+			Name:  "fetch-issue",
+			Usage: "add the title of the issue referenced by the branch name as context (needs GITHUB_TOKEN or GITLAB_TOKEN)",
+		},
+		&cli.StringFlag{
+			Name:  "provider",
+			Usage: "API flavour to use: openai (OpenAI-compatible), gemini-native, or one of the presets groq, mistral and together",
+			Value: providerOpenAI,
+		},
+		&cli.StringFlag{
+			Name:  "model",
+			Usage: "the `MODEL` to use, or one of the aliases from the config",
+		},
+		&cli.StringFlag{
+			Name:  "system-role",
+			Usage: "role of the system prompt message for the openai provider: system or developer",
+		},
+		&cli.StringFlag{
+			Name:  "api-path",
+			Usage: "send the openai provider's requests to `PATH` under the base URL instead of /chat/completions",
+		},
+		&cli.StringFlag{
+			Name:  "api-method",
+			Usage: "HTTP `METHOD` of the openai provider's requests: POST, PUT or PATCH",
+		},
+		&cli.StringFlag{
+			Name:  "prompt-file",
+			Usage: "use the system prompt template at `PATH` or http(s) URL instead of the built-in one",
+		},
+		&cli.StringFlag{
+			Name:  "style-guide",
+			Usage: "add the commit message rules in the Markdown file at `PATH` to the prompt",
+		},
+		&cli.StringMapFlag{
+			Name:  "var",
+			Usage: "set a `KEY=VALUE` variable for the prompt template, available as {{.Vars.KEY}}, can be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "language",
+			Usage: "write the commit message in this language",
+		},
+		&cli.BoolFlag{
+			Name:  "conventional",
+			Usage: "follow the Conventional Commits format",
+			Value: true,
+		},
+		&cli.BoolFlag{
+			Name:  "auto-scope",
+			Usage: "derive the Conventional Commits scope from the directories touched, unless scopes are configured",
+		},
+		&cli.StringFlag{
+			Name:  "history-style",
+			Usage: "learn the style from the author's commits (author), everyone's (repo) or both (blend)",
+		},
+		&cli.StringFlag{
+			Name:  "history-author",
+			Usage: "take style examples from this author's commits instead of yours, `*` for all authors",
+		},
+		&cli.BoolFlag{
+			Name:  "detect-languages",
+			Usage: "tell the model which languages the changed files are written in",
+		},
+		&cli.StringFlag{
+			Name:  "diff-target",
+			Usage: "changes to describe outside the hook: staged, working (unstaged) or head (all)",
+			Value: diffTargetStaged,
+		},
+		&cli.StringFlag{
+			Name:  "diff-algorithm",
+			Usage: "git diff algorithm: myers, minimal, patience or histogram",
+		},
+		&cli.IntFlag{
+			Name:  "context-lines",
+			Usage: "number of unchanged context lines around each hunk (git's -U)",
+		},
+		&cli.BoolFlag{
+			Name:  "function-context",
+			Usage: "show whole functions as context around each change",
+		},
+		&cli.BoolFlag{
+			Name:  "minimal-diff",
+			Usage: "send only added/removed lines and hunk headers, dropping unchanged context",
+		},
+		&cli.IntFlag{
+			Name:  "min-diff-lines",
+			Usage: "skip generation in the hook when fewer lines changed, 0 always generates",
+		},
+		&cli.BoolFlag{
+			Name:  "suggest-split",
+			Usage: "warn when the changes span several unrelated areas and should be split",
+		},
+		&cli.BoolFlag{
+			Name:  "full-deletions",
+			Usage: "send the full content of deleted files instead of a one line summary",
+		},
+		&cli.BoolFlag{
+			Name:  "strip-diff-metadata",
+			Usage: "leave index lines and end of file newline markers out of the diff, on by default",
+		},
+		&cli.BoolFlag{
+			Name:  "submodule-log",
+			Usage: "list the subjects of the commits a submodule update brings in",
+		},
+		&cli.BoolFlag{
+			Name:  "fence-diff",
+			Usage: "wrap the diff in delimiters marking it as untrusted data, against prompt injection",
+		},
+		&cli.BoolFlag{
+			Name:  "base64-diff",
+			Usage: "like --fence-diff, but also base64 encode the diff",
+		},
+		&cli.BoolFlag{
+			Name:  "subject-only",
+			Usage: "generate just a one-line subject, without a body",
+		},
+		&cli.StringFlag{
+			Name:  "variant",
+			Usage: "generate a short and a long message in one request and use the `short` or the `long` one",
+		},
+		&cli.BoolFlag{
+			Name:  "safety-off",
+			Usage: "disable Gemini safety filters (gemini-native only)",
+		},
+		&cli.StringFlag{
+			Name:  "safety-threshold",
+			Usage: "Gemini safety threshold for all categories, e.g. BLOCK_ONLY_HIGH (gemini-native only)",
+		},
+		&cli.FloatFlag{
+			Name:  "top-p",
+			Usage: "nucleus sampling probability (gemini-native only)",
+		},
+		&cli.IntFlag{
+			Name:  "top-k",
+			Usage: "top-k sampling limit (gemini-native only)",
+		},
+		&cli.BoolFlag{
+			Name:  "ascii-only",
+			Usage: "keep the message to ASCII, transliterating or dropping anything else",
+		},
+		&cli.BoolFlag{
+			Name:  "bullets",
+			Usage: "write the message body as a bullet list",
+		},
+		&cli.IntFlag{
+			Name:  "max-bullets",
+			Usage: "in bullet mode, keep at most `N` bullet points, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "wrap",
+			Usage: "wrap the message body at `COLUMN`, 0 to disable",
+			Value: defaultWrapWidth,
+		},
+		&cli.IntFlag{
+			Name:  "max-subject-length",
+			Usage: "shorten longer subjects to `N` characters at a word boundary, 0 for no limit",
+		},
+		&cli.BoolFlag{
+			Name:  "cache",
+			Usage: "reuse the previous response for identical prompts",
+		},
+		&cli.IntFlag{
+			Name:  "candidates",
+			Usage: "generate `N` messages at once and pick one, the first unless --interactive",
+		},
+		&cli.IntFlag{
+			Name:  "max-tokens",
+			Usage: "limit the response to `N` tokens",
+			Value: maxTokens,
+		},
+		&cli.IntFlag{
+			Name:  "empty-retries",
+			Usage: "retry up to `N` times when the model returns an empty message",
+			Value: 1,
+		},
+		&cli.FloatFlag{
+			Name:  "max-cost",
+			Usage: "abort when the estimated cost of a request exceeds `USD`, using the prices from the config",
+		},
+		&cli.FloatFlag{
+			Name:  "rps",
+			Usage: "send at most `N` requests per second to the model API, e.g. 0.25 for one every 4 seconds",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "send at most `N` requests at once when generating several messages",
+			Value: defaultConcurrency,
+		},
+		&cli.BoolFlag{
+			Name:  "map-reduce",
+			Usage: "summarize diffs over --map-reduce-threshold file by file, then write the message from the summaries",
+		},
+		&cli.IntFlag{
+			Name:  "map-reduce-threshold",
+			Usage: "diff size in `BYTES` from which --map-reduce kicks in",
+			Value: defaultMapReduceThreshold,
+		},
+		&cli.IntFlag{
+			Name:  "seed",
+			Usage: "fixed sampling seed, with temperature 0, for reproducible output",
+		},
+		&cli.BoolFlag{
+			Name:  "detect-reverts",
+			Usage: "write git revert's message for changes undoing a recent commit without asking the model, on by default",
+		},
+		&cli.BoolFlag{
+			Name:  "deps-message",
+			Usage: "write the standard chore(deps) message for dependency bumps without asking the model",
+		},
+		&cli.BoolFlag{
+			Name:  "closes-issue",
+			Usage: "add a Closes #N footer to fixes, for the issue in the branch name",
+		},
+		&cli.BoolFlag{
+			Name:  "generated-by",
+			Usage: "add a Generated-by trailer naming the tool version and the model",
+		},
+		&cli.BoolFlag{
+			Name:  "annotate",
+			Usage: "add the message as comments below your draft instead of writing it",
+		},
+		&cli.BoolFlag{
+			Name:  "polish",
+			Usage: "fix the spelling and grammar of the message body with a second request",
+		},
+		&cli.BoolFlag{
+			Name:  "explain",
+			Usage: "print why the model chose the message to stderr",
+		},
+		&cli.BoolFlag{
+			Name:  "suggest-on-message",
+			Usage: "with -m, suggest a message as comments when the editor is opened anyway",
+		},
+		&cli.StringFlag{
+			Name:  "post-command",
+			Usage: "shell `COMMAND` to run with the commit message file path after writing it, e.g. a linter",
+		},
+		&cli.StringFlag{
+			Name:  "clipboard-command",
+			Usage: "shell `COMMAND` generate --clipboard pipes the message to, e.g. \"xclip -selection clipboard\"",
+		},
+		&cli.BoolFlag{
+			Name:  "no-reasoning",
+			Usage: "ask reasoning models not to think before answering",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "fail the commit when the message can't be generated or written, instead of carrying on",
+		},
+		&cli.BoolFlag{
+			Name:  "validate",
+			Usage: "reject obviously bad messages, like an empty one or just \"Update\", instead of writing them",
+		},
+		&cli.BoolFlag{
+			Name:  "force-ci",
+			Usage: "run the hook in CI too, where it's skipped by default",
+		},
+		&cli.BoolFlag{
+			Name:  "interactive",
+			Usage: "ask before acting on failures instead of carrying on",
+		},
+		&cli.BoolFlag{
+			Name:  "verbose",
+			Usage: "report extra details, like the chosen model, on stderr",
+		},
+		&cli.BoolFlag{
+			Name:  "report",
+			Usage: "print the model, tokens, latency, cost and truncation of the run on stderr at the end",
+		},
+		&cli.StringFlag{
+			Name:  "debug-log",
+			Usage: "append full API requests and responses, with the API key redacted, to `FILE`",
+		},
+		&cli.FloatFlag{
+			Name:  "entropy-threshold",
+			Usage: "mask tokens of added lines with at least `BITS` of entropy per character, e.g. 4.5, as likely secrets",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-api-key-in-diff",
+			Usage: "send the diff even if it contains the configured API key",
+		},
+		&cli.BoolFlag{
+			Name:  "anonymize-paths",
+			Usage: "replace file paths with stable pseudonyms, keeping extensions, in what's sent to the model",
+		},
+		&cli.BoolFlag{
+			Name:  "validate-key",
+			Usage: "check the API key with a cheap request before generating",
+		},
+		&cli.BoolFlag{
+			Name:  "as-note",
+			Usage: "attach a suggested message for HEAD as a git note, run from the post-commit hook",
+		},
+		&cli.BoolFlag{
+			Name:  "force-editor",
+			Usage: "open the editor on the message even when git won't, as with git commit -m, run from the hook",
+		},
+	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
-		if cmd.Args().Len() < 1 {
-			return fmt.Errorf("Error: No commit message file provided")
-		}
-
+		// Without a commit message file we're run by hand rather than by the
+		// hook, so the message is only printed
 		commitMsgFile := cmd.Args().Get(0)
 		commitType := ""
 		if cmd.Args().Len() > 1 {
 			commitType = cmd.Args().Get(1)
 		}
 
+		if err := requireGit(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ %s, skipping commit message generation\n", err)
+			return nil
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if cfg.Report {
+			defer report.print()
+		}
+
+		asNote := cmd.Bool("as-note")
+
+		// Scripted commits in CI shouldn't call out to the API by surprise
+		if (commitMsgFile != "" || asNote) && !cfg.ForceCI {
+			if name := ciEnvVar(cfg.CIEnvVars); name != "" {
+				fmt.Fprintf(os.Stderr, "⚠️ Running in CI (%s is set), skipping commit message generation, pass --force-ci to run anyway\n", name)
+				return nil
+			}
+		}
+
+		// A mechanical amend keeps its message, nothing below may touch it
+		if commitMsgFile != "" && isNoEditAmend(commitType) {
+			fmt.Fprintln(os.Stderr, "⚠️ Amending without the editor, keeping the commit message")
+			return nil
+		}
+
+		// git commit -m skips the editor, this opens it once the hook is
+		// done, whatever it did with the message
+		if commitMsgFile != "" && cmd.Bool("force-editor") && !editorShown() {
+			defer func() {
+				if err := runEditor(userEditor(), commitMsgFile, true); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️ Couldn't open the editor: %s\n", err)
+				}
+			}()
+		}
+
+		// Only the staged changes are committed, whatever the config says
+		if commitMsgFile != "" {
+			cfg.DiffTarget = diffTargetStaged
+		}
+
+		// With -m the message is the user's, but when they're about to edit
+		// it anyway a suggestion next to it can help
+		if cfg.SuggestOnMessage && commitType == "message" && editorShown() {
+			cfg.Annotate = true
+		}
+
+		// Nobody would see the annotation without an editor
+		if commitMsgFile != "" && cfg.Annotate && !editorShown() {
+			fmt.Fprintln(os.Stderr, "⚠️ No editor will be shown, skipping the suggestion")
+			return nil
+		}
+
+		// git commit --fixup and --squash prepare the subject, only the body
+		// is left to write
+		fixup := ""
+		if commitMsgFile != "" && commitType == "message" && !cfg.Annotate {
+			fixup = fixupSubject(commitMsgFile)
+		}
+
+		// A draft only saying it's a revert is completed the way git revert
+		// would, no model needed
+		if commitMsgFile != "" && commitType == "message" && !cfg.Annotate && fixup == "" && cfg.DetectReverts {
+			if message := revertDraftMessage(commitMsgFile); message != "" {
+				fmt.Fprintln(os.Stderr, "↩️ Completing the revert message")
+				content, _ := os.ReadFile(commitMsgFile)
+				_, _, comments := splitCommitMessage(string(content))
+				if err := writeCommitMessageFile(commitMsgFile, content, matchLineEndings(comments, string(content))); err != nil {
+					return fmt.Errorf("Failed to prepare commit message file: %w", err)
+				}
+				return updateCommitMessageFile(message, commitMsgFile, cfg.BlankLines, cfg.Separator)
+			}
+		}
+
 		// Skip in these cases
-		if shouldSkip(commitType, commitMsgFile) {
-			fmt.Println("⚠️ Skipping commit message generation")
+		if commitMsgFile != "" && fixup == "" && shouldSkip(commitType, commitMsgFile, cfg.Annotate) {
+			fmt.Fprintln(os.Stderr, "⚠️ Skipping commit message generation")
 			return nil
 		}
 
-		apiKey := os.Getenv("GEMINI_API_KEY")
+		apiKey, source := resolveAPIKey(cfg)
 		if apiKey == "" {
-			fmt.Println("⚠️ GEMINI_API_KEY not set, skipping commit message generation")
+			fmt.Fprintf(os.Stderr, "⚠️ No API key set (%s or %s), skipping commit message generation\n", cfg.keyEnv(), genericKeyEnv)
+			return nil
+		}
+		if cfg.ValidateKey && !keyWorks(cfg, apiKey, source) {
+			fmt.Fprintln(os.Stderr, "⚠️ Skipping commit message generation")
 			return nil
 		}
 
-		// Get diff and changed files
-		diff := getGitDiff()
-		if diff == "" {
+		// Run after the commit, the message is already written
+		if asNote {
+			return noteSuggestion(cfg, apiKey)
+		}
+
+		diff, changedFiles, err := collectChanges(cfg, apiKey)
+		if err != nil || diff == "" {
 			// No changes to commit
+			return err
+		}
+
+		// Tiny changes aren't worth a request
+		if changed := countChangedLines(diff); changed < cfg.MinDiffLines {
+			fmt.Fprintf(os.Stderr, "⚠️ Only %d changed lines, below --min-diff-lines, skipping commit message generation\n", changed)
 			return nil
 		}
 
-		changedFiles := getChangedFiles()
+		if cfg.SuggestSplit {
+			suggestSplit(cfg, diff, changedFiles, apiKey)
+		}
 
-		// Generate message
-		message := generateCommitMessage(diff, changedFiles, apiKey)
-		if message != "" {
-			updateCommitMessageFile(message, commitMsgFile)
+		// Reverts and dependency bumps get a standard message
+		standard := standardMessage(cfg, diff, changedFiles)
+
+		if commitMsgFile == "" {
+			message := standard
+			if message == "" {
+				message = generateCommitMessage(cfg, diff, changedFiles, apiKey)
+			}
+			if err := checkMessage(cfg, message); err != nil {
+				return err
+			}
+			if message != "" {
+				fmt.Println(message)
+			}
+			return nil
 		}
 
-		return nil
+		// Keep the original content around in case the post command asks for
+		// another attempt
+		originalContent, _ := os.ReadFile(commitMsgFile)
+		if fixup != "" {
+			cfg.ExtraInstructions = append(cfg.ExtraInstructions, fixupInstruction(fixup))
+		}
+		for {
+			// Generate message
+			message := standard
+			if message == "" {
+				message = generateCommitMessage(cfg, diff, changedFiles, apiKey)
+			}
+			// Without --strict the commit goes on, with an empty message
+			if err := checkMessage(cfg, message); err != nil {
+				if cfg.Strict {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+				return nil
+			}
+			if message == "" {
+				return nil
+			}
+			if fixup != "" {
+				// The prepared subject is written back along with the body,
+				// only git's comments are kept from the file
+				message = keepFixupSubject(fixup, message)
+				_, _, comments := splitCommitMessage(string(originalContent))
+				if err := writeCommitMessageFile(commitMsgFile, originalContent, matchLineEndings(comments, string(originalContent))); err != nil {
+					return fmt.Errorf("Failed to prepare commit message file: %w", err)
+				}
+			}
+
+			update := updateCommitMessageFile
+			if cfg.Annotate {
+				update = annotateCommitMessageFile
+			}
+			if err := update(message, commitMsgFile, cfg.BlankLines, cfg.Separator); err != nil {
+				if cfg.Strict {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+				return nil
+			}
+
+			if cfg.PostCommand == "" {
+				return nil
+			}
+			if err := runPostCommand(cfg.PostCommand, commitMsgFile); err == nil {
+				return nil
+			}
+
+			if !cfg.Interactive || !confirm("Post command failed, regenerate the message?") {
+				return nil
+			}
+
+			if err := os.WriteFile(commitMsgFile, originalContent, 0644); err != nil {
+				return fmt.Errorf("Failed to restore commit message file: %w", err)
+			}
+
+			// A cached response, or the standard message, would only give the
+			// same message again
+			cfg.Cache = false
+			standard = ""
+		}
 	},
 	Commands: []*cli.Command{
+		generateCmd,
+		regenerateCmd,
+		backfillCmd,
+		restoreCmd,
+		editorCmd,
+		configCmd,
+		initCmd,
 		{
-			Name:  "install",
-			Usage: "Install as a git commit hook",
+			Name:    "install",
+			Usage:   "Install as a git commit hook",
 			Aliases: []string{"i"},
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "skip-editor",
+					Usage: "commit with the generated message right away, without opening the editor",
+				},
+				&cli.BoolFlag{
+					Name:  "force-editor",
+					Usage: "open the editor on the message even with git commit -m",
+				},
+				&cli.BoolFlag{
+					Name:  "suggest-on-message",
+					Usage: "suggest a message as comments when committing with -m and --edit",
+				},
+				&cli.BoolFlag{
+					Name:  "as-note",
+					Usage: "install a post-commit hook attaching the suggestion as a git note instead",
+				},
+			},
 			Action: func(ctx context.Context, cmd *cli.Command) error {
-				// Get the git repository root
-				gitCmd := exec.Command("git", "rev-parse", "--git-dir")
+				if err := requireGit(); err != nil {
+					return err
+				}
+				if cmd.Bool("skip-editor") && cmd.Bool("force-editor") {
+					return fmt.Errorf("Error: --skip-editor and --force-editor can't be combined")
+				}
+
+				// Get the git directory shared by all worktrees, hooks live there
+				// rather than in the per-worktree directory
+				gitCmd := exec.Command("git", "rev-parse", "--git-common-dir")
 				output, err := gitCmd.Output()
 				if err != nil {
 					return fmt.Errorf("Failed to get git directory: %w", err)
 				}
 
 				gitDir := strings.TrimSpace(string(output))
-				hookPath := filepath.Join(gitDir, "hooks", "prepare-commit-msg")
+
+				// Notes are attached once the commit exists, the message
+				// is left to the author
+				hookName := "prepare-commit-msg"
+				if cmd.Bool("as-note") {
+					hookName = "post-commit"
+				}
+				hookPath := filepath.Join(gitDir, "hooks", hookName)
 
 				// Get the path to the current executable
 				execPath, err := os.Executable()
@@ -119,16 +620,35 @@ var rootCmd = &cli.Command{
 					return fmt.Errorf("Failed to create hooks directory: %w", err)
 				}
 
+				hookCommand := execPath
+				if cmd.Bool("as-note") {
+					hookCommand += " --as-note"
+				} else if cmd.Bool("suggest-on-message") {
+					hookCommand += " --suggest-on-message"
+				}
+				if cmd.Bool("force-editor") {
+					hookCommand += " --force-editor"
+				}
+
 				// Create the hook script
 				hookContent := fmt.Sprintf(`#!/bin/sh
 					# Commit message generator hook
 					%s "$@"
-					`, execPath)
+					`, hookCommand)
 
 				if err := os.WriteFile(hookPath, []byte(hookContent), 0755); err != nil {
 					return fmt.Errorf("Failed to write hook file: %w", err)
 				}
 
+				// An editor that accepts commit messages as they are, and opens
+				// the real one for rebases, tags and the like
+				if cmd.Bool("skip-editor") {
+					if err := configureSkipEditor(); err != nil {
+						return err
+					}
+					fmt.Println("✅ Editor skipped for commit messages in this repository, unset core.editor to get it back")
+				}
+
 				fmt.Printf("✅ Commit hook installed at %s\n", hookPath)
 				return nil
 			},
@@ -137,23 +657,52 @@ var rootCmd = &cli.Command{
 }
 
 func main() {
+	rootCmd.Version = currentVersion()
 	if err := rootCmd.Run(context.Background(), os.Args); err != nil {
 		log.Fatal(err)
 		os.Exit(1)
 	}
 }
 
-func shouldSkip(commitType, commitMsgFile string) bool {
+// lookPath finds executables, replaced in tests to pretend git is missing.
+var lookPath = exec.LookPath
+
+// requireGit checks that git can be run at all, since every git helper would
+// otherwise just come back empty.
+func requireGit() error {
+	if _, err := lookPath("git"); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("git not found on PATH, install git or add it to your PATH")
+		}
+		return fmt.Errorf("git can't be run: %w", err)
+	}
+
+	return nil
+}
+
+func shouldSkip(commitType, commitMsgFile string, annotate bool) bool {
+	// Annotations never replace the user's words, so a draft is fine, but
+	// merges, squashes and amends come with a message of their own
+	if annotate {
+		return commitType != "" && commitType != "message" && commitType != "template"
+	}
+
+	// A commit template is boilerplate rather than the user's message, as
+	// long as it's untouched
+	if commitType == "template" && isUnchangedTemplate(commitMsgFile) {
+		return false
+	}
+
 	// Skip if commit type is anything other than an empty message
 	if commitType != "" {
 		return true
 	}
 
-	// Check if the message file already has content
+	// Check if the message file already has content, wherever git's
+	// comments are, as when a hook manager passes the file without the type
 	content, err := os.ReadFile(commitMsgFile)
 	if err == nil {
-		contentStr := strings.TrimSpace(string(content))
-		if contentStr != "" && !strings.HasPrefix(contentStr, "#") {
+		if subject, body, _ := splitCommitMessage(string(content)); subject != "" || body != "" {
 			return true
 		}
 	}
@@ -161,8 +710,100 @@ func shouldSkip(commitType, commitMsgFile string) bool {
 	return false
 }
 
-func getGitDiff() string {
-	cmd := exec.Command("git", "diff", "--staged")
+// isNoEditAmend reports whether git is amending a commit without opening the
+// editor, as with `git commit --amend --no-edit`, so the message must stay
+// exactly as it is.
+func isNoEditAmend(commitType string) bool {
+	return commitType == "commit" && !editorShown()
+}
+
+// collectChanges gathers the staged diff and the list of changed files to
+// send to the model. An empty diff means there is nothing to commit.
+func collectChanges(cfg *Config, apiKey string) (string, string, error) {
+	// Get diff and changed files
+	diff := getGitDiff(cfg)
+	if diff == "" {
+		return "", "", nil
+	}
+
+	diff, err := prepareDiff(cfg, diff, apiKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	files := getChangedFiles(cfg)
+
+	// After git add -p the rest of those files stays out of the commit, and
+	// the model should know it's only seeing part of them
+	if cfg.DiffTarget == diffTargetStaged {
+		if partial := partiallyStaged(changedPaths(files)); len(partial) > 0 {
+			logVerbose(cfg, "✂️ Partially staged: %s", strings.Join(partial, ", "))
+			if cfg.AnonymizePaths {
+				for i, path := range partial {
+					partial[i] = pathPseudonym(path)
+				}
+			}
+			cfg.ExtraInstructions = append(cfg.ExtraInstructions, fmt.Sprintf(
+				"Only some changes of these files are staged, the rest is not part of this commit: %s. "+
+					"Describe only what the diff shows.", strings.Join(partial, ", ")))
+		}
+	}
+
+	// Dependency updates deserve a terse message, unless it's written for
+	// the model anyway
+	if !cfg.DepsMessage {
+		if bumps := dependencyBumps(diff, files); len(bumps) > 0 {
+			cfg.ExtraInstructions = append(cfg.ExtraInstructions, dependencyInstruction(bumps))
+		}
+	}
+
+	return diff, files, nil
+}
+
+// standardMessage is the message for changes that don't need the model: with
+// --detect-reverts the one git revert would write when they undo an earlier
+// commit, or with
+// --deps-message the standard one of a dependency bump. It's empty for any
+// other change.
+func standardMessage(cfg *Config, diff, files string) string {
+	if message := detectedRevertMessage(cfg); message != "" {
+		return message
+	}
+
+	return detectedDependencyMessage(cfg, diff, files)
+}
+
+// prepareDiff applies the configured diff reductions, refuses diffs
+// containing the API key and masks what looks like other secrets.
+func prepareDiff(cfg *Config, diff, apiKey string) (string, error) {
+	if cfg.MinimalDiff {
+		minimal := minimizeDiff(diff)
+		fmt.Fprintf(os.Stderr, "📉 Minimal diff: %d → %d bytes\n", len(diff), len(minimal))
+		report.addTruncation("minimal diff, %d → %d bytes", len(diff), len(minimal))
+		diff = minimal
+	}
+
+	// Never send our own key, it's most likely about to be committed too
+	if apiKey != "" && strings.Contains(diff, apiKey) && !cfg.AllowAPIKeyInDiff {
+		fmt.Fprintln(os.Stderr, "🚨 The staged changes contain your API key!")
+		fmt.Fprintln(os.Stderr, "🚨 Refusing to send them. Unstage the key or pass --allow-api-key-in-diff.")
+		return "", fmt.Errorf("Error: API key found in staged changes")
+	}
+
+	if cfg.EntropyThreshold > 0 {
+		var masked int
+		diff, masked = maskSecrets(diff, cfg.EntropyThreshold, cfg.EntropyAllowlist)
+		if masked > 0 {
+			fmt.Fprintf(os.Stderr, "🔒 Masked %d high-entropy strings that may be secrets\n", masked)
+		}
+	}
+
+	return diff, nil
+}
+
+func getGitDiff(cfg *Config) string {
+	args := append(append([]string{"diff"}, diffTargetArgs(cfg.DiffTarget)...), diffOptions(cfg)...)
+	cmd := exec.Command("git", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -172,8 +813,46 @@ func getGitDiff() string {
 	return diff
 }
 
-func getChangedFiles() string {
-	cmd := exec.Command("git", "diff", "--staged", "--name-status")
+// Diff targets, what the changes are compared against
+const (
+	diffTargetStaged  = "staged"
+	diffTargetWorking = "working"
+	diffTargetHead    = "head"
+)
+
+// diffTargetArgs select the changes of the target for git diff: staged ones
+// against HEAD, unstaged ones against the index, or all of them against HEAD.
+func diffTargetArgs(target string) []string {
+	switch target {
+	case diffTargetWorking:
+		return nil
+	case diffTargetHead:
+		return []string{"HEAD"}
+	default:
+		return []string{"--staged"}
+	}
+}
+
+// diffOptions are the git diff arguments for the configured algorithm and
+// context.
+func diffOptions(cfg *Config) []string {
+	var args []string
+	if cfg.DiffAlgorithm != "" {
+		args = append(args, "--diff-algorithm="+cfg.DiffAlgorithm)
+	}
+	if cfg.ContextLines != nil {
+		args = append(args, fmt.Sprintf("-U%d", *cfg.ContextLines))
+	}
+	if cfg.FunctionContext {
+		args = append(args, "--function-context")
+	}
+
+	return args
+}
+
+func getChangedFiles(cfg *Config) string {
+	args := append(append([]string{"diff"}, diffTargetArgs(cfg.DiffTarget)...), "--name-status")
+	cmd := exec.Command("git", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -182,26 +861,139 @@ func getChangedFiles() string {
 	return string(output)
 }
 
-func getCurrentAuthorRecentCommits() string {
-	// Get current author's email
-	emailCmd := exec.Command("git", "config", "user.email")
-	email, err := emailCmd.Output()
+// partiallyStaged lists the staged paths that also have unstaged changes.
+func partiallyStaged(staged []string) []string {
+	output, err := exec.Command("git", "diff", "--name-only").Output()
+	if err != nil {
+		return nil
+	}
+
+	unstaged := strings.Split(strings.TrimSpace(string(output)), "\n")
+	var partial []string
+	for _, path := range staged {
+		if slices.Contains(unstaged, path) {
+			partial = append(partial, path)
+		}
+	}
+
+	return partial
+}
+
+func getRepoRoot() string {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
 	if err != nil {
-		fmt.Println("⚠️ Couldn't get user email, skipping author commits")
 		return ""
 	}
-	authorEmail := strings.TrimSpace(string(email))
+
+	return strings.TrimSpace(string(output))
+}
+
+// isUnchangedTemplate reports whether the commit message file holds just the
+// configured commit.template, ignoring comments and blank lines.
+func isUnchangedTemplate(commitMsgFile string) bool {
+	output, err := exec.Command("git", "config", "--path", "commit.template").Output()
+	if err != nil {
+		return false
+	}
+
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(getRepoRoot(), path)
+	}
+	template, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	content, err := os.ReadFile(commitMsgFile)
+	if err != nil {
+		return false
+	}
+
+	templateSubject, templateBody, _ := splitCommitMessage(string(template))
+	subject, body, _ := splitCommitMessage(string(content))
+	return subject == templateSubject && body == templateBody
+}
+
+// editorShown reports whether git will open the editor after the hook, git
+// tells hooks it won't by setting GIT_EDITOR to ":". The editor
+// `install --skip-editor` sets opens too, but never shows the message.
+func editorShown() bool {
+	return os.Getenv("GIT_EDITOR") != ":" && !skipsEditor()
+}
+
+// getStyleExamples samples recent commit messages as style examples, from
+// the author, the whole repository or a blend of both depending on the
+// configured history style.
+func getStyleExamples(cfg *Config) string {
+	window := currentHistoryWindow(cfg)
+
+	var examples []string
+	switch cfg.HistoryStyle {
+	case "repo":
+		examples = getAuthorRecentCommits(window, "*", 5)
+	case "blend":
+		// The author's own habits first, the house style fills up the rest
+		examples = getAuthorRecentCommits(window, cfg.HistoryAuthor, 3)
+		for _, msg := range getAuthorRecentCommits(window, "*", 5) {
+			if len(examples) >= 5 {
+				break
+			}
+			if !slices.Contains(examples, msg) {
+				examples = append(examples, msg)
+			}
+		}
+	default:
+		examples = getAuthorRecentCommits(window, cfg.HistoryAuthor, 5)
+	}
+
+	return strings.Join(examples, "\n\n---\n\n")
+}
+
+// getAuthorRecentCommits samples up to limit recent commit messages of the
+// given author within the window. An empty author means the current user,
+// "*" means everyone.
+func getAuthorRecentCommits(window historyWindow, author string, limit int) []string {
+	// Nothing to learn from yet, and git log would fail
+	if !revisionExists(window.revision()) {
+		return nil
+	}
+
+	if author == "" {
+		// Get current author's email
+		emailCmd := exec.Command("git", "config", "user.email")
+		email, err := emailCmd.Output()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "⚠️ Couldn't get user email, skipping author commits")
+			return nil
+		}
+		author = strings.TrimSpace(string(email))
+	}
 
 	// Get recent commits by the author
-	cmd := exec.Command("git", "log", "--author="+authorEmail, "--pretty=format:%B", "-n", "20")
+	args := append([]string{"log", "--pretty=format:%at%x00%B%x1e", "-n", "20"}, window.logArgs()...)
+	if author != "*" {
+		args = append(args, "--author="+author)
+	}
+	cmd := exec.Command("git", args...)
 	output, err := cmd.Output()
 	if err != nil {
-		fmt.Println("⚠️ Couldn't fetch recent commits, skipping author commits")
-		return ""
+		fmt.Fprintln(os.Stderr, "⚠️ Couldn't fetch recent commits, skipping author commits")
+		return nil
 	}
 
-	// Split by commit boundaries and filter
-	commitMsgs := strings.Split(string(output), "\n\n")
+	// Split by commit boundaries, leaving out commits outside the window
+	var commitMsgs []string
+	for _, entry := range strings.Split(string(output), "\x1e") {
+		authorTime, message, found := strings.Cut(strings.TrimLeft(entry, "\n"), "\x00")
+		if !found {
+			continue
+		}
+		if seconds, err := strconv.ParseInt(authorTime, 10, 64); err == nil && !window.includes(seconds) {
+			continue
+		}
+		commitMsgs = append(commitMsgs, strings.Split(strings.TrimSpace(message), "\n\n")...)
+	}
 
 	// Filter to only include messages with more than just a title and optional sign-off-by
 	filteredMsgs := []string{}
@@ -214,94 +1006,305 @@ func getCurrentAuthorRecentCommits() string {
 		}
 
 		filteredMsgs = append(filteredMsgs, msg)
-		if len(filteredMsgs) >= 5 {
+		if len(filteredMsgs) >= limit {
 			break
 		}
 	}
 
-	return strings.Join(filteredMsgs, "\n\n---\n\n")
+	return filteredMsgs
 }
 
-func generateCommitMessage(diff, files, apiKey string) string {
-	fmt.Println("🤖 Generating commit message...")
+func generateCommitMessage(cfg *Config, diff, files, apiKey string) string {
+	// Summarize once for all the candidates
+	cfg = withDiffSummary(cfg, diff, apiKey)
 
-	// Basic prompt with diff and changed files
-	promptText := fmt.Sprintf(`
-		Here are the changed files:
-		%s
+	if cfg.Candidates > 1 {
+		return pickCandidate(cfg, generateCandidates(cfg, diff, files, apiKey))
+	}
 
-		Here is the diff:
-		%s`, files, diff)
+	if cfg.Variant == "" {
+		return generateCommitMessages(cfg, diff, files, apiKey, false).Long
+	}
+
+	variants := generateCommitMessages(cfg, diff, files, apiKey, true)
+	if cfg.Variant == "short" {
+		return variants.Short
+	}
+
+	return variants.Long
+}
+
+// MessageVariants are a one-line and a detailed message for the same change.
+type MessageVariants struct {
+	Short string `json:"short"`
+	Long  string `json:"long"`
+}
+
+// generateCommitMessages generates the message, or with variants both a short
+// and a long one from a single request. Without variants only Long is set.
+func generateCommitMessages(cfg *Config, diff, files, apiKey string, variants bool) MessageVariants {
+	cfg = withDiffSummary(cfg, diff, apiKey)
+	fmt.Fprintln(os.Stderr, "🤖 Generating commit message...")
+
+	subjectOnly := cfg.SubjectOnly && !variants
+	// The rationale only makes sense for a single message
+	explain := cfg.Explain && !variants
+	promptText, scopes, scope := messagePrompt(cfg, diff, files, variants)
+
+	tokens := cfg.MaxTokens
+	if variants {
+		tokens += cfg.MaxTokens
+	}
+	if explain {
+		tokens += cfg.MaxTokens
+	}
 
 	// Read system prompt from embedded file
-	systemRole, err := readPromptFile()
+	systemRole, err := readPromptFile(cfg, files)
 	if err != nil {
-		return ""
+		fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+		return MessageVariants{}
 	}
 
-	// Prepare request
-	messages := []Message{
-		{Role: "system", Content: systemRole},
-		{Role: "user", Content: promptText},
+	completion := CompletionRequest{
+		Model:         cfg.modelFor(diff),
+		System:        systemRole,
+		Prompt:        promptText,
+		MaxTokens:     tokens,
+		Structured:    cfg.Conventional && !subjectOnly && !variants && !explain,
+		FirstLineOnly: subjectOnly && !explain,
+		JSON:          explain,
 	}
 
-	requestData := OpenAIRequest{
-		Model:       model,
-		Messages:    messages,
-		MaxTokens:   maxTokens,
-		Temperature: 0.3,
+	var result MessageVariants
+	for attempt := 0; attempt <= cfg.EmptyRetries; attempt++ {
+		if attempt > 0 {
+			fmt.Fprintf(os.Stderr, "🔁 Empty message, retrying (%d/%d)...\n", attempt, cfg.EmptyRetries)
+			completion.TemperatureBoost = float64(attempt) * retryTemperatureBoost
+		}
+
+		text, answered := completeAnswered(cfg, completion, apiKey)
+		message := stripReasoning(text, cfg.ReasoningPatterns)
+		if explain {
+			var rationale string
+			message, rationale = parseExplanation(message)
+			if rationale != "" {
+				fmt.Fprintf(os.Stderr, "💡 %s\n", rationale)
+			}
+		}
+		if !variants {
+			if cfg.Polish && !subjectOnly {
+				message = polishMessage(cfg, message, completion.Model, apiKey)
+			}
+			result = MessageVariants{Long: finishMessage(cfg, message, scopes, scope, subjectOnly, completion.Model)}
+		} else {
+			short, long := splitVariants(message)
+			if cfg.Polish {
+				long = polishMessage(cfg, long, completion.Model, apiKey)
+			}
+			result = MessageVariants{
+				Short: finishMessage(cfg, short, scopes, scope, true, completion.Model),
+				Long:  finishMessage(cfg, long, scopes, scope, false, completion.Model),
+			}
+		}
+
+		// Only an answer that came out empty is worth asking for again, a
+		// failure would just repeat
+		if strings.TrimSpace(result.Long) != "" || !answered {
+			break
+		}
 	}
 
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		fmt.Printf("❌ Error creating JSON request: %s\n", err)
-		return ""
+	return result
+}
+
+// messagePrompt is the user prompt asking for the message: the changes with
+// every instruction the config and the command add. It also returns the scopes
+// the message is kept to and the one the changes belong to.
+func messagePrompt(cfg *Config, diff, files string, variants bool) (string, []ScopeRule, string) {
+	promptText := buildUserPrompt(cfg, diff, files)
+
+	// Keep scopes within the configured dictionary, or the one derived from
+	// the directories touched, unless that would name a real directory
+	scopes := cfg.Scopes
+	if len(scopes) == 0 && cfg.AutoScope && !cfg.AnonymizePaths {
+		if name := directoryScope(changedPaths(files)); name != "" {
+			scopes = []ScopeRule{{Name: name}}
+		}
+	}
+	scope := dominantScope(scopes, changedPaths(files))
+	if cfg.Conventional && len(scopes) > 0 {
+		promptText += "\n\n" + scopeInstruction(scopes, scope)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", apiEndpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		fmt.Printf("❌ Error creating HTTP request: %s\n", err)
-		return ""
+	if !cfg.Conventional {
+		promptText += "\n\nDo not use the Conventional Commits format, write a plain subject without a type or scope prefix."
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if cfg.Bullets && !cfg.SubjectOnly {
+		promptText += "\n\n" + bulletInstruction(cfg.MaxBullets)
+	}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("❌ Error sending request: %s\n", err)
-		return ""
+	if cfg.Language != "" {
+		promptText += fmt.Sprintf("\n\nWrite the commit message in %s.", cfg.Language)
 	}
-	defer resp.Body.Close()
 
-	// Process response
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("❌ API error (status %d): %s\n", resp.StatusCode, body)
-		return ""
+	for _, instruction := range cfg.ExtraInstructions {
+		promptText += "\n\n" + instruction
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("❌ Error reading response: %s\n", err)
-		return ""
+	if cfg.FetchIssue {
+		if title := fetchIssueTitle(); title != "" {
+			promptText += fmt.Sprintf("\n\nThis change implements the issue: %s", title)
+		}
 	}
 
-	var openAIResp OpenAIResponse
-	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		fmt.Printf("❌ Error parsing response: %s\n", err)
-		return ""
+	if cfg.ASCIIOnly {
+		promptText += "\n\n" + asciiInstruction
 	}
 
-	if len(openAIResp.Choices) == 0 {
-		fmt.Println("❌ No message generated")
-		return ""
+	if cfg.SubjectOnly && !variants {
+		promptText += "\n\nRespond with the commit subject line only, without a body."
 	}
 
-	message := openAIResp.Choices[0].Message.Content
+	if variants {
+		promptText += "\n\n" + variantsInstruction
+	}
+
+	// The rationale only makes sense for a single message
+	if cfg.Explain && !variants {
+		promptText += "\n\n" + explainInstruction
+	}
+
+	return promptText, scopes, scope
+}
+
+// finishMessage runs a generated message through the configured
+// post-processors, in order. An empty message stays empty.
+func finishMessage(cfg *Config, message string, scopes []ScopeRule, scope string, subjectOnly bool, model string) string {
+	steps := postProcessors(cfg, scopes, scope, subjectOnly, model)
+	for _, name := range cfg.PostProcessors {
+		if step := steps[name]; step != nil && strings.TrimSpace(message) != "" {
+			message = step(message)
+		}
+	}
+
+	return message
+}
+
+// variantsInstruction asks for both variants in one response, in the layout
+// splitVariants understands.
+const variantsInstruction = "Write two variants of the commit message: first a short one, the subject line only, " +
+	"then a line containing only ---, then a detailed one with the subject line, a blank line and the body."
+
+// splitVariants separates the short and the long variant of a response. If
+// the model ignored the layout, the short variant is the subject of the
+// whole response.
+func splitVariants(response string) (string, string) {
+	response = strings.ReplaceAll(strings.TrimSpace(response), "\r\n", "\n")
+	if short, long, found := strings.Cut(response, "\n---\n"); found {
+		return strings.TrimSpace(short), strings.TrimSpace(long)
+	}
+
+	return response, response
+}
+
+// buildUserPrompt lays out the changed files and the diff for the model,
+// along with any hints matching the touched files.
+func buildUserPrompt(cfg *Config, diff, files string) string {
+	diff = describeSubmodules(diff, cfg.SubmoduleLog, cfg.AnonymizePaths)
+
+	// The real paths are still needed for the hints and languages below
+	shownFiles := files
+	if cfg.AnonymizePaths {
+		diff = anonymizeDiff(diff)
+		shownFiles = anonymizeFiles(files)
+	}
+
+	if !cfg.FullDeletions {
+		diff = summarizeDeletions(diff)
+	}
+	if cfg.StripDiffMetadata {
+		diff = stripDiffMetadata(diff)
+	}
+	diff = collapseLongLines(diff)
+
+	if cfg.FenceDiff || cfg.Base64Diff {
+		diff = fenceUntrustedDiff(diff, cfg.Base64Diff)
+	}
+
+	// Basic prompt with diff and changed files
+	promptText := fmt.Sprintf(`
+		Here are the changed files:
+		%s
+
+		Here is the diff:
+		%s`, shownFiles, diff)
+
+	// Too large to send, the summary of each file stands in for the diff
+	if cfg.DiffSummary != "" {
+		promptText = fmt.Sprintf(`
+		Here are the changed files:
+		%s
+
+		The diff is too large to show, here is a summary of the changes to each file:
+		%s`, shownFiles, cfg.DiffSummary)
+	}
+
+	if cfg.DetectLanguages {
+		if languages := detectLanguages(changedPaths(files)); languages != "" {
+			promptText += "\n\nLanguages touched by this change: " + languages
+		}
+	}
+
+	// Add hints for the kinds of files touched by this change
+	if hints := matchPromptHints(cfg.Hints, changedPaths(files)); len(hints) > 0 {
+		promptText += "\n\nKeep in mind:\n- " + strings.Join(hints, "\n- ")
+	}
+
+	return promptText
+}
+
+// reportEmptyCompletion explains why a completion came back without content,
+// based on the finish reason reported by the provider.
+func reportEmptyCompletion(finishReason string) {
+	switch strings.ToLower(finishReason) {
+	case "content_filter", "safety", "prohibited_content", "blocklist", "spii", "recitation":
+		fmt.Fprintf(os.Stderr, "❌ Response blocked by the provider's content filter (%s)\n", finishReason)
+	case "length", "max_tokens":
+		fmt.Fprintln(os.Stderr, "❌ No message generated, the token limit was reached before any text, raise --max-tokens")
+	default:
+		fmt.Fprintln(os.Stderr, "❌ No message generated, the model returned empty content")
+	}
+}
+
+// trimTruncated drops the unfinished last line of a completion cut off by
+// the token limit, so a sentence ending halfway never gets committed.
+func trimTruncated(text, finishReason string) string {
+	switch strings.ToLower(finishReason) {
+	case "length", "max_tokens":
+	default:
+		return text
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return text
+	}
+	report.addTruncation("response cut at the token limit")
+
+	lastNewline := strings.LastIndex(text, "\n")
+	if lastNewline < 0 {
+		fmt.Fprintln(os.Stderr, "⚠️ The message hit the token limit, the subject may be incomplete, raise --max-tokens")
+		return text
+	}
+
+	fmt.Fprintln(os.Stderr, "⚠️ The message hit the token limit, dropped the unfinished last line, raise --max-tokens for longer messages")
+	return strings.TrimSpace(text[:lastNewline])
+}
+
+// cleanMessage strips the wrapping some models put around the message.
+func cleanMessage(message string) string {
 	message = strings.TrimSpace(message)
 
 	// Clean up message - remove quotes if API returned them
@@ -316,17 +1319,44 @@ func generateCommitMessage(diff, files, apiKey string) string {
 	return message
 }
 
-func readPromptFile() (string, error) {
+// readPromptFile renders the system prompt template for the changed files.
+func readPromptFile(cfg *Config, files string) (string, error) {
+	promptSource := systemPrompt
+	if isPromptURL(cfg.PromptFile) {
+		promptSource = fetchPrompt(cfg, cfg.PromptFile)
+	} else if cfg.PromptFile != "" {
+		content, err := os.ReadFile(expandHome(cfg.PromptFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		promptSource = string(content)
+	}
+
 	// Parse the prompt as a Go template
-	tmpl, err := template.New("systemprompt").Parse(systemPrompt)
+	tmpl, err := template.New("systemprompt").Option("missingkey=error").Parse(promptSource)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse prompt template: %w", err)
 	}
 
+	vars := cfg.Vars
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	// Keep the history out of prompts about security sensitive code
+	styleExamples := ""
+	if path := sensitivePath(cfg.SensitivePaths, changedPaths(files)); path != "" {
+		fmt.Fprintf(os.Stderr, "🔒 %s is security sensitive, leaving recent commits out of the prompt\n", path)
+	} else {
+		styleExamples = getStyleExamples(cfg)
+	}
+
 	promptData := struct {
 		LastFiveCommits string
+		Vars            map[string]string
 	}{
-		LastFiveCommits: getCurrentAuthorRecentCommits(),
+		LastFiveCommits: styleExamples,
+		Vars:            vars,
 	}
 
 	var buf bytes.Buffer
@@ -334,7 +1364,21 @@ func readPromptFile() (string, error) {
 		return "", fmt.Errorf("failed to execute prompt template: %w", err)
 	}
 
-	return strings.TrimSpace(buf.String()), nil
+	prompt := strings.TrimSpace(buf.String())
+	if projectContext := readProjectContext(); projectContext != "" {
+		prompt += "\n\n**Project Context (domain terms and conventions to respect):**\n\n" + projectContext
+	}
+
+	// The team's rules come last, so they win over the defaults above
+	if cfg.StyleGuide != "" {
+		styleGuide, err := readCappedFile(expandHome(cfg.StyleGuide), maxStyleGuideBytes)
+		if err != nil {
+			return "", fmt.Errorf("failed to read style guide: %w", err)
+		}
+		prompt += "\n\n**Style Guide (these rules take precedence over everything above):**\n\n" + styleGuide
+	}
+
+	return prompt, nil
 }
 
 func stripMarkdownFences(message string) string {
@@ -361,18 +1405,92 @@ func stripMarkdownFences(message string) string {
 	return message
 }
 
-func updateCommitMessageFile(message, commitMsgFile string) {
+func updateCommitMessageFile(message, commitMsgFile string, blankLines int, separator string) error {
 	existingContent, err := os.ReadFile(commitMsgFile)
 	if err != nil {
-		fmt.Printf("❌ Error reading commit message file: %s\n", err)
-		return
+		return fmt.Errorf("Error reading commit message file: %w", err)
 	}
 
-	// Combine generated message with existing content
-	newContent := fmt.Sprintf("%s\n\n%s", message, string(existingContent))
+	// Exactly one newline ends the message, followed by the configured
+	// blank lines and separator when there is existing content to separate
+	message = strings.TrimRight(strings.TrimLeft(message, "\r\n"), " \t\r\n") + "\n"
+	existing := strings.TrimLeft(string(existingContent), "\r\n")
+	if existing != "" {
+		message += messageSeparator(blankLines, separator)
+	}
+
+	// Combine generated message with existing content, keeping the file's
+	// line endings so CRLF files don't end up mixed
+	newContent := matchLineEndings(message, existing) + existing
 
-	err = os.WriteFile(commitMsgFile, []byte(newContent), 0644)
+	err = writeCommitMessageFile(commitMsgFile, existingContent, newContent)
 	if err != nil {
-		fmt.Printf("❌ Error writing commit message file: %s\n", err)
+		return fmt.Errorf("Error writing commit message file: %w", err)
 	}
+
+	return nil
+}
+
+// annotateCommitMessageFile adds the generated message as comment lines below
+// the user's draft, so it's only committed if the user uncomments it.
+func annotateCommitMessageFile(message, commitMsgFile string, blankLines int, separator string) error {
+	existingContent, err := os.ReadFile(commitMsgFile)
+	if err != nil {
+		return fmt.Errorf("Error reading commit message file: %w", err)
+	}
+
+	annotation := []string{"# Suggested commit message, uncomment to use it:", "#"}
+	for _, line := range strings.Split(strings.TrimSpace(message), "\n") {
+		annotation = append(annotation, strings.TrimRight("# "+line, " "))
+	}
+
+	// The suggestion goes between the draft and git's own comments
+	existing := strings.ReplaceAll(string(existingContent), "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	draftEnd := len(lines)
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			draftEnd = i
+			break
+		}
+	}
+	draft := strings.TrimRight(strings.Join(lines[:draftEnd], "\n"), "\n")
+	comments := strings.Join(lines[draftEnd:], "\n")
+
+	newContent := strings.Join(annotation, "\n") + "\n"
+	if draft != "" {
+		newContent = draft + "\n" + strings.Repeat("\n", blankLines) + newContent
+	}
+	if comments != "" {
+		newContent += messageSeparator(blankLines, separator) + comments + "\n"
+	}
+
+	err = writeCommitMessageFile(commitMsgFile, existingContent, matchLineEndings(newContent, string(existingContent)))
+	if err != nil {
+		return fmt.Errorf("Error writing commit message file: %w", err)
+	}
+
+	return nil
+}
+
+// messageSeparator goes between the generated message and the existing
+// content of the commit message file: the blank lines, then the separator
+// line, if any.
+func messageSeparator(blankLines int, separator string) string {
+	if separator == "" {
+		return strings.Repeat("\n", blankLines)
+	}
+
+	return strings.Repeat("\n", blankLines) + strings.TrimRight(separator, "\r\n") + "\n"
+}
+
+// matchLineEndings converts the newlines of text to CRLF when the reference
+// content uses CRLF line endings, and to LF otherwise.
+func matchLineEndings(text, reference string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.Contains(reference, "\r\n") {
+		return text
+	}
+
+	return strings.ReplaceAll(text, "\n", "\r\n")
 }
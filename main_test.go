@@ -0,0 +1,493 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runInstall runs the install command with the flags.
+func runInstall(t *testing.T, args ...string) error {
+	t.Helper()
+
+	return rootCmd.Command("install").Run(context.Background(), append([]string{"install"}, args...))
+}
+
+func TestInstallHookLocation(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, repo string)
+	}{
+		{"repository", func(t *testing.T, repo string) {}},
+		{"subdirectory", func(t *testing.T, repo string) {
+			writeFile(t, filepath.Join(repo, "a", "b", "file"), "")
+			chdir(t, filepath.Join(repo, "a", "b"))
+		}},
+		{"linked worktree", func(t *testing.T, repo string) {
+			worktree := filepath.Join(t.TempDir(), "feature")
+			runGit(t, "worktree", "add", "-q", "-b", "feature", worktree)
+			chdir(t, worktree)
+		}},
+		{"worktree subdirectory", func(t *testing.T, repo string) {
+			worktree := filepath.Join(t.TempDir(), "feature")
+			runGit(t, "worktree", "add", "-q", "-b", "feature", worktree)
+			writeFile(t, filepath.Join(worktree, "sub", "file"), "")
+			chdir(t, filepath.Join(worktree, "sub"))
+		}},
+		{"GIT_DIR", func(t *testing.T, repo string) {
+			t.Setenv("GIT_DIR", filepath.Join(repo, ".git"))
+			chdir(t, t.TempDir())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			repo := initRepo(t)
+			commitFile(t, "README.md", "# Test\n", "docs: add readme")
+			tt.setup(t, repo)
+
+			if err := runInstall(t); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(filepath.Join(repo, ".git", "hooks", "prepare-commit-msg"))
+			if err != nil {
+				t.Fatalf("no hook in the common git directory: %s", err)
+			}
+			executable, _ := os.Executable()
+			if !strings.Contains(string(content), executable+` "$@"`) {
+				t.Errorf("hook doesn't run commitment:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestUpdateCommitMessageFileErrors(t *testing.T) {
+	isolate(t)
+	dir := t.TempDir()
+
+	err := updateCommitMessageFile("feat: add x", filepath.Join(dir, "missing", "COMMIT_EDITMSG"), 1, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "Error reading commit message file") {
+		t.Errorf("missing file: %v", err)
+	}
+	err = annotateCommitMessageFile("feat: add x", filepath.Join(dir, "missing", "COMMIT_EDITMSG"), 1, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "Error reading commit message file") {
+		t.Errorf("missing file annotated: %v", err)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions don't apply to root")
+	}
+	path := filepath.Join(dir, "COMMIT_EDITMSG")
+	writeFile(t, path, "# Please enter the commit message\n")
+	if err := os.Chmod(path, 0444); err != nil {
+		t.Fatal(err)
+	}
+	err = updateCommitMessageFile("feat: add x", path, 1, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "Error writing commit message file") {
+		t.Errorf("read-only file: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "# Please enter the commit message\n" {
+		t.Errorf("read-only file changed to %q", content)
+	}
+}
+
+// gitComments are the comments git writes into the commit message file.
+const gitComments = "# Please enter the commit message for your changes.\n# On branch main\n"
+
+func TestCommitMessageFileLineEndings(t *testing.T) {
+	crlf := strings.ReplaceAll(gitComments, "\n", "\r\n")
+	tests := []struct {
+		name     string
+		message  string
+		existing string
+		annotate bool
+		want     string
+	}{
+		{"LF", "feat: add x\n\nWhy.", gitComments, false, "feat: add x\n\nWhy.\n\n" + gitComments},
+		{"CRLF", "feat: add x\n\nWhy.", crlf, false, "feat: add x\r\n\r\nWhy.\r\n\r\n" + crlf},
+		{"CRLF message into LF", "feat: add x\r\n\r\nWhy.\r\n", gitComments, false, "feat: add x\n\nWhy.\n\n" + gitComments},
+		{"CRLF annotated", "feat: add x\n\nWhy.", "wip\r\n" + crlf, true, "wip\r\n\r\n# Suggested commit message, uncomment to use it:\r\n#\r\n# feat: add x\r\n#\r\n# Why.\r\n\r\n" + crlf},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			path := filepath.Join(initRepo(t), ".git", "COMMIT_EDITMSG")
+			writeFile(t, path, tt.existing)
+
+			update := updateCommitMessageFile
+			if tt.annotate {
+				update = annotateCommitMessageFile
+			}
+			if err := update(tt.message, path, 1, ""); err != nil {
+				t.Fatal(err)
+			}
+			content, _ := os.ReadFile(path)
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateCommitMessageFileSpacing(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string
+		existing   string
+		blankLines int
+		want       string
+	}{
+		{"default", "feat: add x\n\nWhy.", gitComments, 1, "feat: add x\n\nWhy.\n\n" + gitComments},
+		{"stray newlines trimmed", "\nfeat: add x\n\nWhy.\n\n\n", gitComments, 1, "feat: add x\n\nWhy.\n\n" + gitComments},
+		{"trailing spaces trimmed", "feat: add x  \t\n", gitComments, 1, "feat: add x\n\n" + gitComments},
+		{"blank lines before the existing content dropped", "feat: add x", "\n\n" + gitComments, 1, "feat: add x\n\n" + gitComments},
+		{"no blank line", "feat: add x", gitComments, 0, "feat: add x\n" + gitComments},
+		{"two blank lines", "feat: add x", gitComments, 2, "feat: add x\n\n\n" + gitComments},
+		{"empty file", "feat: add x\n\n", "", 1, "feat: add x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			path := filepath.Join(initRepo(t), ".git", "COMMIT_EDITMSG")
+			writeFile(t, path, tt.existing)
+
+			if err := updateCommitMessageFile(tt.message, path, tt.blankLines, ""); err != nil {
+				t.Fatal(err)
+			}
+			content, _ := os.ReadFile(path)
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlankLinesConfig(t *testing.T) {
+	path := isolate(t)
+	cfg, err := loadTestConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BlankLines != 1 {
+		t.Errorf("default blank_lines = %d, want 1", cfg.BlankLines)
+	}
+
+	writeFile(t, path, `{"blank_lines": -1}`)
+	if _, err := loadTestConfig(t); err == nil || !strings.Contains(err.Error(), "blank_lines can't be negative") {
+		t.Errorf("negative blank_lines: %v", err)
+	}
+}
+
+func TestFirstCommit(t *testing.T) {
+	isolate(t)
+	dir := initRepo(t)
+	writeFile(t, "README.md", "# Test\n")
+	runGit(t, "add", "README.md")
+	path := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+	writeFile(t, path, gitComments)
+	t.Setenv(genericKeyEnv, "key")
+	requests := fakeOpenAI(t, &Config{}, toolCallResponse(`{"type": "docs", "subject": "add readme"}`, "tool_calls"))
+
+	for _, author := range []string{"", "*"} {
+		if examples := getAuthorRecentCommits(historyWindow{}, author, 5); examples != nil {
+			t.Errorf("examples of %q = %q without commits", author, examples)
+		}
+	}
+
+	var err error
+	stderr := captureStderr(t, func() {
+		err = runRoot(t, "--provider", "fake", "--history-style", "blend", path)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stderr, "⚠️") || strings.Contains(stderr, "❌") {
+		t.Errorf("warnings for the first commit:\n%s", stderr)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "docs: add readme\n\n"+gitComments {
+		t.Errorf("commit message file = %q", content)
+	}
+}
+
+func TestShouldSkipCommitTemplate(t *testing.T) {
+	template, err := os.ReadFile(filepath.Join("testdata", "commit_template.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	absolute, _ := filepath.Abs(filepath.Join("testdata", "commit_template.txt"))
+
+	tests := []struct {
+		name       string
+		configured string
+		content    string
+		commitType string
+		skip       bool
+	}{
+		{"untouched template", absolute, string(template) + gitComments, "template", false},
+		{"template in the repository", ".gitmessage", string(template) + gitComments, "template", false},
+		{"edited template", absolute, "fix: typo\n\n" + string(template), "template", true},
+		{"another template", absolute, "Ticket: ABC-1\n" + gitComments, "template", true},
+		{"no template configured", "", string(template), "template", true},
+		{"empty message", absolute, gitComments, "", false},
+		{"message", absolute, "fix: typo\n", "message", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			dir := initRepo(t)
+			writeFile(t, ".gitmessage", string(template))
+			if tt.configured != "" {
+				runGit(t, "config", "commit.template", tt.configured)
+			}
+			path := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+			writeFile(t, path, tt.content)
+
+			if got := shouldSkip(tt.commitType, path, false); got != tt.skip {
+				t.Errorf("shouldSkip() = %v, want %v", got, tt.skip)
+			}
+		})
+	}
+}
+
+func TestCommitTemplateMessage(t *testing.T) {
+	template, err := filepath.Abs(filepath.Join("testdata", "commit_template.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	isolate(t)
+	dir := initRepo(t)
+	runGit(t, "config", "commit.template", template)
+	writeFile(t, "main.go", "package main\n")
+	runGit(t, "add", "main.go")
+	content, _ := os.ReadFile(template)
+	path := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+	writeFile(t, path, string(content)+gitComments)
+	t.Setenv(genericKeyEnv, "key")
+	fakeOpenAI(t, &Config{}, toolCallResponse(`{"type": "feat", "subject": "add main"}`, "tool_calls"))
+
+	if err := runRoot(t, "--provider", "fake", path, "template"); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := os.ReadFile(path)
+	if want := "feat: add main\n\n" + string(content) + gitComments; string(written) != want {
+		t.Errorf("commit message file = %q, want %q", written, want)
+	}
+}
+
+func TestMessageSeparator(t *testing.T) {
+	tests := []struct {
+		blankLines int
+		separator  string
+		want       string
+	}{
+		{1, "", "\n"},
+		{0, "", ""},
+		{1, "# ---", "\n# ---\n"},
+		{0, "# ---\n", "# ---\n"},
+		{2, "# ---\n# Generated above\n", "\n\n# ---\n# Generated above\n"},
+	}
+	for _, tt := range tests {
+		if got := messageSeparator(tt.blankLines, tt.separator); got != tt.want {
+			t.Errorf("messageSeparator(%d, %q) = %q, want %q", tt.blankLines, tt.separator, got, tt.want)
+		}
+	}
+}
+
+func TestCommitMessageFileSeparator(t *testing.T) {
+	crlf := strings.ReplaceAll(gitComments, "\n", "\r\n")
+	tests := []struct {
+		name     string
+		existing string
+		annotate bool
+		want     string
+	}{
+		{"comments", gitComments, false, "feat: add x\n\n# ---\n" + gitComments},
+		{"CRLF", crlf, false, "feat: add x\r\n\r\n# ---\r\n" + crlf},
+		{"empty file", "", false, "feat: add x\n"},
+		{"annotated", "wip\n" + gitComments, true, "wip\n\n# Suggested commit message, uncomment to use it:\n#\n# feat: add x\n\n# ---\n" + gitComments},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			path := filepath.Join(initRepo(t), ".git", "COMMIT_EDITMSG")
+			writeFile(t, path, tt.existing)
+
+			update := updateCommitMessageFile
+			if tt.annotate {
+				update = annotateCommitMessageFile
+			}
+			if err := update("feat: add x", path, 1, "# ---"); err != nil {
+				t.Fatal(err)
+			}
+			content, _ := os.ReadFile(path)
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeparatorLeftOutOfTheCommit(t *testing.T) {
+	isolate(t)
+	dir := initRepo(t)
+	writeFile(t, "main.go", "package main\n")
+	runGit(t, "add", "main.go")
+	path := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+	writeFile(t, path, gitComments)
+	if err := updateCommitMessageFile("feat: add main", path, 1, "# ---"); err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(t, "commit", "-q", "-F", path, "--cleanup=strip")
+	if message := runGit(t, "log", "-1", "--format=%B"); strings.TrimSpace(message) != "feat: add main" {
+		t.Errorf("committed message = %q", message)
+	}
+}
+
+func TestSeparatorConfig(t *testing.T) {
+	path := isolate(t)
+	cfg, err := loadTestConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Separator != "" {
+		t.Errorf("default separator = %q, want none", cfg.Separator)
+	}
+
+	writeFile(t, path, `{"separator": "# ---\n# above is generated"}`)
+	if cfg, err = loadTestConfig(t); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Separator != "# ---\n# above is generated" {
+		t.Errorf("separator = %q", cfg.Separator)
+	}
+
+	writeFile(t, path, `{"separator": "---"}`)
+	if _, err := loadTestConfig(t); err == nil || !strings.Contains(err.Error(), "separator lines must be comments") {
+		t.Errorf("separator that isn't a comment: %v", err)
+	}
+}
+
+// withoutGit makes the git lookup fail with err for the rest of the test.
+func withoutGit(t *testing.T, err error) {
+	t.Helper()
+
+	lookPath = func(file string) (string, error) {
+		return "", &exec.Error{Name: file, Err: err}
+	}
+	t.Cleanup(func() { lookPath = exec.LookPath })
+}
+
+func TestRequireGit(t *testing.T) {
+	if err := requireGit(); err != nil {
+		t.Fatalf("requireGit() = %v with git installed", err)
+	}
+
+	withoutGit(t, exec.ErrNotFound)
+	if err := requireGit(); err == nil || err.Error() != "git not found on PATH, install git or add it to your PATH" {
+		t.Errorf("requireGit() = %v without git", err)
+	}
+
+	withoutGit(t, os.ErrPermission)
+	if err := requireGit(); err == nil || !strings.HasPrefix(err.Error(), "git can't be run: ") {
+		t.Errorf("requireGit() = %v with git not executable", err)
+	}
+}
+
+func TestHookWithoutGit(t *testing.T) {
+	isolate(t)
+	dir := initRepo(t)
+	writeFile(t, "main.go", "package main\n")
+	runGit(t, "add", "main.go")
+	path := filepath.Join(dir, ".git", "COMMIT_EDITMSG")
+	writeFile(t, path, gitComments)
+	t.Setenv(genericKeyEnv, "key")
+	requests := fakeOpenAI(t, &Config{})
+	withoutGit(t, exec.ErrNotFound)
+
+	var err error
+	stderr := captureStderr(t, func() {
+		err = runRoot(t, "--provider", "fake", path)
+	})
+	if err != nil {
+		t.Fatalf("the hook failed the commit: %s", err)
+	}
+	if !strings.Contains(stderr, "git not found on PATH") || !strings.Contains(stderr, "skipping commit message generation") {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("sent %d requests, want none", len(*requests))
+	}
+	if content, _ := os.ReadFile(path); string(content) != gitComments {
+		t.Errorf("commit message file = %q, want it untouched", content)
+	}
+}
+
+func TestEmptyRetries(t *testing.T) {
+	ok := textResponse("fix: typo", "stop")
+	tests := []struct {
+		name     string
+		statuses []int
+		bodies   []string
+		budget   bool
+		requests int
+		want     string
+	}{
+		{"empty answer", []int{200, 200}, []string{textResponse("", "stop"), ok}, false, 2, "fix: typo"},
+		{"whitespace answer", []int{200, 200}, []string{textResponse(" \n\t", "stop"), ok}, false, 2, "fix: typo"},
+		{"empty after cleaning", []int{200, 200}, []string{textResponse("```\n```", "stop"), ok}, false, 2, "fix: typo"},
+		{"rejected key", []int{401}, []string{`{"error": "bad key"}`}, false, 1, ""},
+		{"forbidden", []int{403}, []string{`{"error": "forbidden"}`}, false, 1, ""},
+		{"no choices", []int{200}, []string{`{"choices": []}`}, false, 1, ""},
+		{"over budget", nil, nil, true, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests > len(tt.bodies) {
+					t.Errorf("unexpected request %d", requests)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(tt.statuses[requests-1])
+				w.Write([]byte(tt.bodies[requests-1]))
+			}))
+			defer server.Close()
+			providerPresets["fake"] = ProviderPreset{BaseURL: server.URL, Features: allFeatures}
+			defer delete(providerPresets, "fake")
+
+			cfg, err := loadTestConfig(t, "--provider", "fake", "--empty-retries", "3", "--conventional=false")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.budget {
+				cfg.Prices = map[string]Price{cfg.modelFor(""): {Input: 1_000_000, Output: 1_000_000}}
+				cfg.MaxCost = 0.01
+			}
+
+			var got MessageVariants
+			stderr := captureStderr(t, func() { got = generateCommitMessages(cfg, "diff --git a/f b/f\n+x\n", "M\tf", "key", false) })
+			if got.Long != tt.want {
+				t.Errorf("message = %q, want %q", got.Long, tt.want)
+			}
+			if requests != tt.requests {
+				t.Errorf("sent %d requests, want %d", requests, tt.requests)
+			}
+			if retried := strings.Contains(stderr, "retrying"); retried != (tt.requests > 1) {
+				t.Errorf("stderr = %q", stderr)
+			}
+		})
+	}
+}
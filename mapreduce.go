@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// defaultMapReduceThreshold is the diff size, in bytes, from which the diff
+// is summarized file by file instead of sent whole.
+const defaultMapReduceThreshold = 200000
+
+// fileSummaryPrompt asks for the summary of one file of a larger change.
+const fileSummaryPrompt = "You summarize one file of a larger code change, for someone writing its commit message. " +
+	"Describe in one or two short sentences what changed in the file and why, if the diff tells. " +
+	"Respond with the summary only."
+
+// FileDiff is the part of a diff about a single file.
+type FileDiff struct {
+	Path string
+	Diff string
+}
+
+// splitFileDiffs cuts a diff into the parts about each file, in order.
+func splitFileDiffs(diff string) []FileDiff {
+	var files []FileDiff
+	for _, line := range strings.Split(diff, "\n") {
+		if header, ok := strings.CutPrefix(line, "diff --git "); ok {
+			files = append(files, FileDiff{Path: diffHeaderPath(header)})
+		}
+		if len(files) == 0 {
+			continue
+		}
+		current := &files[len(files)-1]
+		current.Diff += line + "\n"
+	}
+
+	return files
+}
+
+// withDiffSummary returns the config to generate the message with: when the
+// diff is too large to send whole, a copy carrying a summary of each file
+// made by smaller concurrent requests, otherwise the config as it is. The
+// summary replaces the diff in the prompt.
+func withDiffSummary(cfg *Config, diff, apiKey string) *Config {
+	if !cfg.MapReduce || cfg.DiffSummary != "" || len(diff) <= cfg.MapReduceThreshold {
+		return cfg
+	}
+
+	files := splitFileDiffs(diff)
+	fmt.Fprintf(os.Stderr, "✂️ The diff is %d bytes, summarizing its %d files one by one\n", len(diff), len(files))
+	report.addTruncation("diff of %d bytes summarized file by file", len(diff))
+
+	// One request per file, none of them fanning out again
+	single := *cfg
+	single.Candidates = 0
+
+	summaries := make([]string, len(files))
+	limit := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			summaries[i] = summarizeFileDiff(&single, file, apiKey)
+		}()
+	}
+	wg.Wait()
+
+	var summary strings.Builder
+	summarized := 0
+	for i, file := range files {
+		label := file.Path
+		if cfg.AnonymizePaths {
+			label = pathPseudonym(file.Path)
+		}
+		text := summaries[i]
+		if text == "" {
+			text = "(no summary)"
+		} else {
+			summarized++
+		}
+		fmt.Fprintf(&summary, "- %s: %s\n", label, text)
+	}
+	if summarized == 0 {
+		fmt.Fprintln(os.Stderr, "⚠️ Couldn't summarize any file, sending the whole diff")
+		return cfg
+	}
+
+	summarizedCfg := *cfg
+	summarizedCfg.DiffSummary = strings.TrimSpace(summary.String())
+	return &summarizedCfg
+}
+
+// summarizeFileDiff asks for the summary of one file's diff, cut to the
+// threshold if the file alone is too large. It's empty when the request fails.
+func summarizeFileDiff(cfg *Config, file FileDiff, apiKey string) string {
+	diff := collapseLongLines(file.Diff)
+	if cfg.AnonymizePaths {
+		diff = anonymizeDiff(diff)
+	}
+	if !cfg.FullDeletions {
+		diff = summarizeDeletions(diff)
+	}
+	if cfg.StripDiffMetadata {
+		diff = stripDiffMetadata(diff)
+	}
+	if len(diff) > cfg.MapReduceThreshold {
+		report.addTruncation("diff of %s cut to %d bytes", file.Path, cfg.MapReduceThreshold)
+		diff = cutAtRune(diff, cfg.MapReduceThreshold) + "\n<rest of the diff cut>"
+	}
+
+	completion := CompletionRequest{
+		Model:     cfg.modelFor(diff),
+		System:    fileSummaryPrompt,
+		Prompt:    diff,
+		MaxTokens: cfg.MaxTokens,
+	}
+	summary := stripMarkdownFences(stripReasoning(complete(cfg, completion, apiKey), cfg.ReasoningPatterns))
+
+	return strings.Join(strings.Fields(summary), " ")
+}
+
+// cutAtRune cuts the text to at most max bytes without splitting a character.
+func cutAtRune(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	for max > 0 && !utf8.RuneStart(text[max]) {
+		max--
+	}
+
+	return text[:max]
+}
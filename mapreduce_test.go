@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCutAtRune(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{"abc", 5, "abc"},
+		{"abc", 2, "ab"},
+		{"zażółć", 3, "za"},
+		{"zażółć", 4, "zaż"},
+		{"日本語", 5, "日"},
+		{"日本語", 2, ""},
+	}
+	for _, tt := range tests {
+		if got := cutAtRune(tt.text, tt.max); got != tt.want {
+			t.Errorf("cutAtRune(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeFileDiffCutsOnRuneBoundary(t *testing.T) {
+	cfg := &Config{MapReduceThreshold: 57, MaxTokens: 50}
+	requests := fakeOpenAI(t, cfg, textResponse("Translates the greeting.", "stop"))
+	diff := "diff --git a/hello.txt b/hello.txt\n+" + strings.Repeat("żółw ", 20) + "\n"
+
+	summary := summarizeFileDiff(cfg, FileDiff{Path: "hello.txt", Diff: diff}, "key")
+	if summary != "Translates the greeting." {
+		t.Errorf("summary = %q", summary)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	prompt := (*requests)[0].Messages[1].Content
+	if strings.ContainsRune(prompt, utf8.RuneError) || !strings.HasSuffix(prompt, "\n<rest of the diff cut>") {
+		t.Errorf("prompt = %q, want valid UTF-8 cut before the marker", prompt)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// defaultBadMessages are subjects too vague to commit, compared without case,
+// trailing punctuation or the Conventional Commits type.
+var defaultBadMessages = []string{
+	"update", "updates", "updated", "fix", "fixes", "change", "changes", "wip", "misc", "commit", "commit message",
+}
+
+var (
+	// A template placeholder left unfilled, like <description> or [scope]
+	rePlaceholder = regexp.MustCompile(`[<\[](?:type|scope|subject|description|summary|message|issue)[>\]]`)
+	// The type and scope in front of a Conventional Commits subject
+	reConventionalPrefix = regexp.MustCompile(`^[a-z]+(\([^)]*\))?!?:\s*`)
+)
+
+// messageProblem describes why the message is obviously bad, or is empty when
+// it isn't: an empty message, one without a single letter or digit, one
+// with a template placeholder left in, or a subject that's just one of the
+// rejected words.
+func messageProblem(message string, badMessages []string) string {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "the message is empty"
+	}
+	if !strings.ContainsFunc(message, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return "the message is only punctuation"
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	if placeholder := rePlaceholder.FindString(strings.ToLower(subject)); placeholder != "" {
+		return fmt.Sprintf("the subject still has the placeholder %s", placeholder)
+	}
+
+	words := reConventionalPrefix.ReplaceAllString(strings.ToLower(strings.TrimSpace(subject)), "")
+	words = strings.TrimRight(words, ".!… ")
+	for _, bad := range badMessages {
+		if words == strings.ToLower(bad) {
+			return fmt.Sprintf("the subject is just %q", bad)
+		}
+	}
+
+	return ""
+}
+
+// checkMessage rejects an obviously bad message with --validate or --strict,
+// and lets anything through otherwise.
+func checkMessage(cfg *Config, message string) error {
+	if !cfg.Validate && !cfg.Strict {
+		return nil
+	}
+	if problem := messageProblem(message, cfg.BadMessages); problem != "" {
+		return fmt.Errorf("Error: Rejected the generated message, %s", problem)
+	}
+
+	return nil
+}
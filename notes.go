@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultNotesRef keeps the suggestions apart from any other notes.
+const defaultNotesRef = "commitment"
+
+// noteSuggestion generates a message for the commit just made and attaches
+// it to HEAD as a git note, so the suggestion can be compared with what the
+// author actually wrote. It replaces an earlier note on the same commit.
+func noteSuggestion(cfg *Config, apiKey string) error {
+	output, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("Error: No commit to attach a note to")
+	}
+	commit := strings.TrimSpace(string(output))
+
+	diff, err := prepareDiff(cfg, commitDiff(cfg, commit), apiKey)
+	if err != nil || diff == "" {
+		return err
+	}
+
+	// The style examples come from before the commit, not the commit itself
+	cfg.HistoryBase = commit
+	message := generateCommitMessage(cfg, diff, commitFiles(commit), apiKey)
+	if message == "" {
+		return fmt.Errorf("Error: No message generated")
+	}
+
+	if err := exec.Command("git", "notes", "--ref", cfg.NotesRef, "add", "-f", "-m", message, commit).Run(); err != nil {
+		return fmt.Errorf("Failed to add note: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "📝 Suggestion attached to %s, see it with git log --notes=%s\n", shortCommit(commit), cfg.NotesRef)
+	return nil
+}
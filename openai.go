@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type OpenAIRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature float64   `json:"temperature"`
+	Seed        *int      `json:"seed,omitempty"`
+	Tools       []Tool    `json:"tools,omitempty"`
+	ToolChoice  any       `json:"tool_choice,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+
+	// ResponseFormat {"type": "json_object"} makes the model respond with JSON
+	ResponseFormat any `json:"response_format,omitempty"`
+
+	// ReasoningEffort "none" turns off thinking on models that support it
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type ToolCall struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type OpenAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string     `json:"content"`
+			ToolCalls []ToolCall `json:"tool_calls"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// CommitParts holds the structured fields the model fills in through the
+// commit_message tool.
+type CommitParts struct {
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	Breaking bool   `json:"breaking"`
+}
+
+const commitMessageTool = "commit_message"
+
+// toolCallTokenOverhead is added to the token budget of structured requests
+// for the JSON wrapping the message: the field names, quotes and escapes.
+const toolCallTokenOverhead = 80
+
+var commitMessageToolSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"type":     map[string]any{"type": "string", "description": "Conventional Commits type, e.g. feat, fix, refactor"},
+		"scope":    map[string]any{"type": "string", "description": "Optional scope noun, empty when not applicable"},
+		"subject":  map[string]any{"type": "string", "description": "Imperative subject line without type and scope"},
+		"body":     map[string]any{"type": "string", "description": "Optional body explaining why the change was made"},
+		"breaking": map[string]any{"type": "boolean", "description": "Whether the change is a breaking change"},
+	},
+	"required": []string{"type", "subject"},
+}
+
+// completeWithOpenAI generates the message through the OpenAI-compatible
+// chat completions API, asking for structured parts through tool calling
+// where the provider supports it. It also reports whether the API answered,
+// see completeAnswered.
+func completeWithOpenAI(cfg *Config, completion CompletionRequest, apiKey string) (string, bool) {
+	messages := []Message{
+		{Role: cfg.systemRole(completion.Model), Content: completion.System},
+		{Role: "user", Content: completion.Prompt},
+	}
+
+	requestData := OpenAIRequest{
+		Model:       completion.Model,
+		Messages:    messages,
+		MaxTokens:   completion.MaxTokens,
+		Temperature: cfg.temperature() + completion.TemperatureBoost,
+		Seed:        cfg.Seed,
+	}
+	if cfg.NoReasoning {
+		requestData.ReasoningEffort = "none"
+	}
+	if completion.JSON {
+		requestData.ResponseFormat = map[string]string{"type": "json_object"}
+	}
+
+	// Only the first line is needed, stream it and stop there
+	if completion.FirstLineOnly {
+		requestData.Stream = true
+		return streamFirstLine(cfg, requestData, apiKey)
+	}
+
+	if !completion.Structured {
+		return completePlain(cfg, requestData, apiKey)
+	}
+
+	// The JSON of the tool call costs tokens on top of the message, cut off
+	// it can't be parsed at all
+	requestData.MaxTokens += toolCallTokenOverhead
+	requestData.Tools = []Tool{{
+		Type: "function",
+		Function: ToolFunction{
+			Name:        commitMessageTool,
+			Description: "Record the generated commit message",
+			Parameters:  commitMessageToolSchema,
+		},
+	}}
+	requestData.ToolChoice = map[string]any{
+		"type":     "function",
+		"function": map[string]string{"name": commitMessageTool},
+	}
+
+	plain := requestData
+	plain.Tools = nil
+	plain.ToolChoice = nil
+	plain.MaxTokens = completion.MaxTokens
+
+	openAIResp, toolsRejected := sendChatRequest(cfg, requestData, apiKey)
+	if toolsRejected {
+		// Provider doesn't understand tools, fall back to plain completion
+		return completePlain(cfg, plain, apiKey)
+	}
+	if openAIResp == nil {
+		return "", false
+	}
+
+	if len(openAIResp.Choices) == 0 {
+		fmt.Fprintln(os.Stderr, "❌ No message generated, the API returned no choices")
+		return "", false
+	}
+
+	choice := openAIResp.Choices[0]
+	for _, call := range choice.Message.ToolCalls {
+		if call.Function.Name != commitMessageTool {
+			continue
+		}
+
+		var parts CommitParts
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &parts); err != nil || !parts.complete() {
+			break
+		}
+
+		return parts.String(), true
+	}
+
+	if strings.TrimSpace(choice.Message.Content) != "" {
+		return trimTruncated(choice.Message.Content, choice.FinishReason), true
+	}
+
+	// A tool call cut off or missing its fields still leaves the plain
+	// message to ask for
+	fmt.Fprintln(os.Stderr, "⚠️ The structured message was incomplete, retrying with plain completion")
+	return completePlain(cfg, plain, apiKey)
+}
+
+// completePlain sends a chat completion asking for free text and returns the
+// message, trimmed to its last complete line when it was cut off, and whether
+// the API answered.
+func completePlain(cfg *Config, requestData OpenAIRequest, apiKey string) (string, bool) {
+	openAIResp, _ := sendChatRequest(cfg, requestData, apiKey)
+	if openAIResp == nil {
+		return "", false
+	}
+	if len(openAIResp.Choices) == 0 {
+		fmt.Fprintln(os.Stderr, "❌ No message generated, the API returned no choices")
+		return "", false
+	}
+	if strings.TrimSpace(openAIResp.Choices[0].Message.Content) == "" {
+		reportEmptyCompletion(openAIResp.Choices[0].FinishReason)
+	}
+
+	return trimTruncated(openAIResp.Choices[0].Message.Content, openAIResp.Choices[0].FinishReason), true
+}
+
+// newChatRequest encodes the request body and prepares an authenticated HTTP
+// request against the API endpoint, at the configured path and method.
+func newChatRequest(cfg *Config, requestData OpenAIRequest, apiKey string) (*http.Request, error) {
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating JSON request: %w", err)
+	}
+
+	req, err := http.NewRequest(cfg.APIMethod, cfg.baseURL()+cfg.APIPath, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("User-Agent", userAgent())
+
+	return req, nil
+}
+
+// sendChatRequest posts the request to the API and decodes the response. On
+// failure it reports the error and returns a nil response, along with whether
+// the API rejected the tools of the request, so it's worth sending without.
+func sendChatRequest(cfg *Config, requestData OpenAIRequest, apiKey string) (*OpenAIResponse, bool) {
+	req, err := newChatRequest(cfg, requestData, apiKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+		return nil, false
+	}
+
+	// Send request
+	waitForRequest(cfg)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		debugLog(cfg, apiKey, req, 0, []byte(err.Error()))
+		fmt.Fprintf(os.Stderr, "❌ Error sending request: %s\n", err)
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	debugLog(cfg, apiKey, req, resp.StatusCode, body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading response: %s\n", err)
+		return nil, false
+	}
+
+	// Process response
+	if resp.StatusCode != http.StatusOK {
+		// Gemini answers a bad key or model with a 400 too, only an error
+		// about the tools means they're the problem
+		if resp.StatusCode == http.StatusBadRequest && requestData.Tools != nil && mentionsTools(body) {
+			fmt.Fprintln(os.Stderr, "⚠️ Tool calling not supported, retrying with plain completion")
+			return nil, true
+		}
+		fmt.Fprintf(os.Stderr, "❌ API error (status %d): %s\n", resp.StatusCode, body)
+		return nil, false
+	}
+
+	var openAIResp OpenAIResponse
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error parsing response: %s\n", err)
+		return nil, false
+	}
+
+	reportUsage(cfg, requestData.Model, openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+	return &openAIResp, false
+}
+
+// mentionsTools reports whether an error response is about tool calling.
+func mentionsTools(body []byte) bool {
+	text := strings.ToLower(string(body))
+	for _, word := range []string{"tool", "function call", "function_call", "functions"} {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// complete reports whether the parts make a valid Conventional Commits
+// header, a type that's a single word and a subject.
+func (p CommitParts) complete() bool {
+	kind := strings.TrimSpace(p.Type)
+	return kind != "" && !strings.ContainsAny(kind, " \t():!") && strings.TrimSpace(p.Subject) != ""
+}
+
+// String assembles the parts into a Conventional Commits message.
+func (p CommitParts) String() string {
+	var header strings.Builder
+	header.WriteString(strings.TrimSpace(p.Type))
+	if scope := strings.TrimSpace(p.Scope); scope != "" {
+		header.WriteString("(" + scope + ")")
+	}
+	if p.Breaking {
+		header.WriteString("!")
+	}
+	header.WriteString(": " + strings.TrimSpace(p.Subject))
+
+	message := header.String()
+	if body := strings.TrimSpace(p.Body); body != "" {
+		message += "\n\n" + body
+	}
+
+	return message
+}
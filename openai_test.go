@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeOpenAI serves the chat completions API from the responses in order,
+// under a temporary preset the config selects. It returns the decoded
+// requests.
+func fakeOpenAI(t *testing.T, cfg *Config, responses ...string) *[]OpenAIRequest {
+	t.Helper()
+
+	var requests []OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request OpenAIRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("invalid request: %s", err)
+		}
+		requests = append(requests, request)
+
+		if len(requests) > len(responses) {
+			t.Errorf("unexpected request %d", len(requests))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(responses[len(requests)-1]))
+	}))
+	t.Cleanup(server.Close)
+
+	providerPresets["fake"] = ProviderPreset{BaseURL: server.URL, Features: allFeatures}
+	t.Cleanup(func() { delete(providerPresets, "fake") })
+	cfg.Provider = "fake"
+	if cfg.APIPath == "" {
+		cfg.APIPath = defaultAPIPath
+	}
+	if cfg.APIMethod == "" {
+		cfg.APIMethod = defaultAPIMethod
+	}
+
+	return &requests
+}
+
+// toolCallResponse is a response calling the commit_message tool.
+func toolCallResponse(arguments, finishReason string) string {
+	encoded, _ := json.Marshal(arguments)
+	return `{"choices": [{"message": {"content": "", "tool_calls": [{"type": "function", "function": {"name": "commit_message", "arguments": ` +
+		string(encoded) + `}}]}, "finish_reason": "` + finishReason + `"}]}`
+}
+
+// textResponse is a response with plain content.
+func textResponse(content, finishReason string) string {
+	encoded, _ := json.Marshal(content)
+	return `{"choices": [{"message": {"content": ` + string(encoded) + `}, "finish_reason": "` + finishReason + `"}]}`
+}
+
+func TestCompleteWithOpenAIStructured(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		want      string
+		requests  int
+	}{
+		{
+			"tool call",
+			[]string{toolCallResponse(`{"type": "fix", "scope": "api", "subject": "handle nil", "body": "It panicked."}`, "tool_calls")},
+			"fix(api): handle nil\n\nIt panicked.",
+			1,
+		},
+		{
+			"breaking",
+			[]string{toolCallResponse(`{"type": "feat", "subject": "drop v1", "breaking": true}`, "tool_calls")},
+			"feat!: drop v1",
+			1,
+		},
+		{
+			"cut off at the token limit",
+			[]string{toolCallResponse(`{"type": "fix", "subject": "handle ni`, "length"), textResponse("fix: handle nil", "stop")},
+			"fix: handle nil",
+			2,
+		},
+		{
+			"no type",
+			[]string{toolCallResponse(`{"subject": "do it"}`, "tool_calls"), textResponse("chore: do it", "stop")},
+			"chore: do it",
+			2,
+		},
+		{
+			"type with spaces",
+			[]string{toolCallResponse(`{"type": "bug fix", "subject": "do it"}`, "tool_calls"), textResponse("fix: do it", "stop")},
+			"fix: do it",
+			2,
+		},
+		{
+			"content instead of a tool call",
+			[]string{textResponse("docs: explain setup", "stop")},
+			"docs: explain setup",
+			1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			requests := fakeOpenAI(t, cfg, tt.responses...)
+
+			got, _ := completeWithOpenAI(cfg, CompletionRequest{Model: "m", Prompt: "diff", MaxTokens: 120, Structured: true}, "key")
+			if got != tt.want {
+				t.Errorf("completeWithOpenAI() = %q, want %q", got, tt.want)
+			}
+			if len(*requests) != tt.requests {
+				t.Fatalf("sent %d requests, want %d", len(*requests), tt.requests)
+			}
+
+			first := (*requests)[0]
+			if len(first.Tools) != 1 || first.MaxTokens != 120+toolCallTokenOverhead {
+				t.Errorf("structured request had %d tools and %d max tokens", len(first.Tools), first.MaxTokens)
+			}
+			if tt.requests > 1 {
+				retry := (*requests)[1]
+				if len(retry.Tools) != 0 || retry.ToolChoice != nil || retry.MaxTokens != 120 {
+					t.Errorf("plain retry had %d tools and %d max tokens", len(retry.Tools), retry.MaxTokens)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitPartsString(t *testing.T) {
+	tests := []struct {
+		parts    CommitParts
+		complete bool
+		want     string
+	}{
+		{CommitParts{Type: "feat", Subject: "add x"}, true, "feat: add x"},
+		{CommitParts{Type: " fix ", Scope: " ui ", Subject: " align ", Body: " Why. "}, true, "fix(ui): align\n\nWhy."},
+		{CommitParts{Type: "feat", Scope: "api", Subject: "drop v1", Breaking: true}, true, "feat(api)!: drop v1"},
+		{CommitParts{Subject: "do it"}, false, ""},
+		{CommitParts{Type: "fix"}, false, ""},
+		{CommitParts{Type: "fix(ui)", Subject: "x"}, false, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.parts.complete(); got != tt.complete {
+			t.Errorf("%+v complete() = %v, want %v", tt.parts, got, tt.complete)
+		}
+		if tt.complete && tt.parts.String() != tt.want {
+			t.Errorf("%+v String() = %q, want %q", tt.parts, tt.parts.String(), tt.want)
+		}
+	}
+}
+
+func TestToolCallFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		error    string
+		fallback bool
+	}{
+		{"tools unsupported", `{"error": {"message": "tools are not supported by this model"}}`, true},
+		{"tool_choice rejected", `{"error": {"message": "Invalid value for tool_choice"}}`, true},
+		{"function calling unsupported", `{"error": {"message": "Function calling is not enabled for this model"}}`, true},
+		{"bad key", `[{"error": {"code": 400, "message": "API key not valid. Please pass a valid API key.", "status": "INVALID_ARGUMENT"}}]`, false},
+		{"bad model", `[{"error": {"code": 400, "message": "models/gemini-9 is not found for API version v1main", "status": "INVALID_ARGUMENT"}}]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(tt.error))
+					return
+				}
+				w.Write([]byte(textResponse("fix: handle nil", "stop")))
+			}))
+			defer server.Close()
+			providerPresets["fake"] = ProviderPreset{BaseURL: server.URL, Features: allFeatures}
+			defer delete(providerPresets, "fake")
+
+			cfg := &Config{Provider: "fake", APIPath: defaultAPIPath, APIMethod: defaultAPIMethod}
+			var got string
+			stderr := captureStderr(t, func() {
+				got, _ = completeWithOpenAI(cfg, CompletionRequest{Model: "m", Prompt: "diff", MaxTokens: 120, Structured: true}, "key")
+			})
+
+			want, wantRequests := "", 1
+			if tt.fallback {
+				want, wantRequests = "fix: handle nil", 2
+			}
+			if got != want || requests != wantRequests {
+				t.Errorf("completeWithOpenAI() = %q after %d requests, want %q after %d", got, requests, want, wantRequests)
+			}
+			if notice := strings.Contains(stderr, "Tool calling not supported"); notice != tt.fallback {
+				t.Errorf("stderr = %q", stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// partialRepo commits main.go and util.go, then stages the change of the
+// first function of main.go and of util.go, leaving the change of the second
+// function of main.go unstaged, as `git add -p` would.
+func partialRepo(t *testing.T) {
+	t.Helper()
+
+	initRepo(t)
+	writeFile(t, "util.go", "package main\n")
+	commitFile(t, "main.go", "package main\n\nfunc first() {}\n\n\n\n\n\n\nfunc second() {}\n", "feat: add main")
+	writeFile(t, "main.go", "package main\n\nfunc first() { println(1) }\n\n\n\n\n\n\nfunc second() {}\n")
+	writeFile(t, "util.go", "package main\n\nfunc util() {}\n")
+	runGit(t, "add", "main.go", "util.go")
+	writeFile(t, "main.go", "package main\n\nfunc first() { println(1) }\n\n\n\n\n\n\nfunc second() { println(2) }\n")
+}
+
+func TestPartiallyStaged(t *testing.T) {
+	isolate(t)
+	partialRepo(t)
+
+	if got := partiallyStaged([]string{"main.go", "util.go"}); !slices.Equal(got, []string{"main.go"}) {
+		t.Errorf("partiallyStaged() = %q, want main.go", got)
+	}
+	runGit(t, "add", "main.go")
+	if got := partiallyStaged([]string{"main.go", "util.go"}); len(got) != 0 {
+		t.Errorf("partiallyStaged() = %q with everything staged", got)
+	}
+}
+
+func TestCollectChangesPartiallyStaged(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"staged", Config{DiffTarget: diffTargetStaged}, "these files are staged, the rest is not part of this commit: main.go."},
+		{"anonymized", Config{DiffTarget: diffTargetStaged, AnonymizePaths: true}, "not part of this commit: " + pathPseudonym("main.go") + "."},
+		{"working tree", Config{DiffTarget: diffTargetWorking}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			partialRepo(t)
+
+			cfg := tt.cfg
+			diff, files, err := collectChanges(&cfg, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			instructions := strings.Join(cfg.ExtraInstructions, "\n")
+			if tt.want == "" {
+				if strings.Contains(instructions, "staged") {
+					t.Errorf("instructions = %q, want none about staging", instructions)
+				}
+				return
+			}
+			if !strings.Contains(instructions, tt.want) {
+				t.Errorf("instructions = %q, want %q", instructions, tt.want)
+			}
+			if strings.Contains(diff, "println(2)") || !strings.Contains(diff, "println(1)") {
+				t.Errorf("diff isn't just the staged hunk:\n%s", diff)
+			}
+			if paths := changedPaths(files); !slices.Equal(paths, []string{"main.go", "util.go"}) {
+				t.Errorf("changed files = %q", paths)
+			}
+		})
+	}
+}
+
+func TestPromptNotesPartialStaging(t *testing.T) {
+	isolate(t)
+	partialRepo(t)
+	cfg, err := loadTestConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, files, err := collectChanges(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, _, _ := messagePrompt(cfg, diff, files, false)
+	if !strings.Contains(prompt, "Only some changes of these files are staged") {
+		t.Errorf("prompt doesn't note the partial staging:\n%s", prompt)
+	}
+}
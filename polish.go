@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// polishPrompt asks for a proofread of the message, not a rewrite.
+const polishPrompt = "You proofread commit messages. Fix the spelling and grammar of the commit message body you are given " +
+	"without changing its meaning, wording choices or structure. Keep technical terms, identifiers, code, lists and trailers " +
+	"as they are. Respond with the corrected body only."
+
+// polishMessage cleans up the grammar of the message body with a second,
+// lightweight request. The subject is kept as it is, and so is the whole
+// message when there is no body or the request fails.
+func polishMessage(cfg *Config, message, model, apiKey string) string {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return message
+	}
+
+	fmt.Fprintln(os.Stderr, "✏️ Polishing the message...")
+	completion := CompletionRequest{
+		Model:     model,
+		System:    polishPrompt,
+		Prompt:    body,
+		MaxTokens: cfg.MaxTokens + len(body)/charsPerToken,
+	}
+	polished := stripMarkdownFences(stripReasoning(complete(cfg, completion, apiKey), cfg.ReasoningPatterns))
+	if polished == "" {
+		fmt.Fprintln(os.Stderr, "⚠️ Couldn't polish the message, keeping it as it is")
+		return message
+	}
+
+	return subject + "\n\n" + polished
+}
@@ -0,0 +1,105 @@
+package main
+
+import "strings"
+
+// postProcessor is one step of the cleanup applied to a generated message.
+type postProcessor func(string) string
+
+// defaultPostProcessors lists every step, in the order they run unless the
+// config says otherwise. The closing footer goes before the skeleton, whose
+// ticket prefix hides the Conventional Commits header it looks for.
+var defaultPostProcessors = []string{
+	"clean", "disclaimers", "spacing", "subject", "scope", "length", "bullets", "ascii", "wrap", "closes", "skeleton", "trailer", "template",
+}
+
+// postProcessors builds the steps by name for a message generated with the
+// given scopes and model. Steps the settings turn off are left out.
+func postProcessors(cfg *Config, scopes []ScopeRule, scope string, subjectOnly bool, model string) map[string]postProcessor {
+	steps := map[string]postProcessor{
+		"clean": cleanMessage,
+		"disclaimers": func(message string) string {
+			return stripDisclaimers(message, cfg.DisclaimerPatterns)
+		},
+		"spacing": normalizeSpacing,
+		"wrap": func(message string) string {
+			return wrapBody(message, cfg.WrapWidth)
+		},
+	}
+
+	if subjectOnly {
+		steps["subject"] = func(message string) string {
+			subject, _, _ := strings.Cut(message, "\n")
+			return strings.TrimSpace(subject)
+		}
+	}
+
+	if cfg.Conventional && len(scopes) > 0 {
+		steps["scope"] = func(message string) string {
+			return enforceScope(message, scopes, scope)
+		}
+	}
+
+	if cfg.MaxSubjectLength > 0 {
+		steps["length"] = func(message string) string {
+			return shortenSubject(message, cfg.MaxSubjectLength)
+		}
+	}
+
+	if cfg.Bullets {
+		steps["bullets"] = func(message string) string {
+			return limitBullets(message, cfg.MaxBullets)
+		}
+	}
+
+	if cfg.ASCIIOnly {
+		steps["ascii"] = toASCII
+	}
+
+	if cfg.Skeleton != nil {
+		steps["skeleton"] = func(message string) string {
+			return cfg.Skeleton.compose(message, getCurrentBranch())
+		}
+	}
+
+	if cfg.ClosesIssue {
+		steps["closes"] = func(message string) string {
+			return closesFooter(message, getCurrentBranch(), cfg.ClosesFormat, cfg.ClosesTypes)
+		}
+	}
+
+	if cfg.GeneratedBy {
+		steps["trailer"] = func(message string) string {
+			return appendTrailer(message, generatedByTrailer(model))
+		}
+	}
+
+	if cfg.OutputTemplate != "" {
+		steps["template"] = func(message string) string {
+			return renderOutputTemplate(cfg, message)
+		}
+	}
+
+	return steps
+}
+
+// normalizeSpacing puts exactly one blank line between the subject and the
+// body, as git expects, and collapses runs of blank lines in the body, however
+// the model spaced them.
+func normalizeSpacing(message string) string {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	result := []string{lines[0]}
+	blank := true
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if blank {
+			result = append(result, "")
+		}
+		result = append(result, line)
+		blank = false
+	}
+
+	return strings.Join(result, "\n")
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCleanMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"  fix: typo \n", "fix: typo"},
+		{`"fix: typo"`, "fix: typo"},
+		{"'fix: typo'", "fix: typo"},
+		{"```\nfix: typo\n\nWhy.\n```", "fix: typo\n\nWhy."},
+		{"```text\nfix: typo\n```", "fix: typo"},
+		{"```\nfix: typo", "fix: typo"},
+		{"```", ""},
+		{`fix: quote "names"`, `fix: quote "names"`},
+	}
+	for _, tt := range tests {
+		if got := cleanMessage(tt.message); got != tt.want {
+			t.Errorf("cleanMessage(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestStripDisclaimers(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"fix: typo\n\nWhy.", "fix: typo\n\nWhy."},
+		{"fix: typo\n\nWhy.\n\nLet me know if you want changes!", "fix: typo\n\nWhy."},
+		{"fix: typo\n\nHope this helps.\n\nThis commit message was generated by AI.\n", "fix: typo"},
+		{"fix: typo\n\nLet me know what breaks, it's untested.\n\nWhy.", "fix: typo\n\nLet me know what breaks, it's untested.\n\nWhy."},
+		{"Would you like me to add tests?", "Would you like me to add tests?"},
+	}
+	for _, tt := range tests {
+		if got := stripDisclaimers(tt.message, defaultDisclaimerPatterns); got != tt.want {
+			t.Errorf("stripDisclaimers(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+
+	// Invalid patterns are skipped
+	if got := stripDisclaimers("fix: typo\n\nSee you", []string{"(", "^See you$"}); got != "fix: typo" {
+		t.Errorf("stripDisclaimers() with an invalid pattern = %q", got)
+	}
+}
+
+func TestWrapBody(t *testing.T) {
+	long := "This sentence is long enough that it has to be wrapped somewhere around the configured width."
+	tests := []struct {
+		name    string
+		message string
+		width   int
+		want    string
+	}{
+		{"subject only", "feat: " + long, 20, "feat: " + long},
+		{"off", "fix: x\n\n" + long, 0, "fix: x\n\n" + long},
+		{"prose", "fix: x\n\n" + long, 40, "fix: x\n\nThis sentence is long enough that it has\nto be wrapped somewhere around the\nconfigured width."},
+		{"joins short lines", "fix: x\n\nOne\ntwo three.", 40, "fix: x\n\nOne two three."},
+		{"paragraphs", "fix: x\n\nFirst one.\n\nSecond one.", 40, "fix: x\n\nFirst one.\n\nSecond one."},
+		{"bullets", "fix: x\n\n- " + long, 40, "fix: x\n\n- " + long},
+		{"code", "fix: x\n\n```\n" + long + "\n```", 40, "fix: x\n\n```\n" + long + "\n```"},
+		{"indented", "fix: x\n\n    " + long, 40, "fix: x\n\n    " + long},
+		{"trailers", "fix: x\n\nSigned-off-by: Someone With A Very Long Name <someone@example.com>", 40, "fix: x\n\nSigned-off-by: Someone With A Very Long Name <someone@example.com>"},
+		{"long word", "fix: x\n\nSee https://example.com/a/very/long/link/that/cannot/be/broken", 20, "fix: x\n\nSee\nhttps://example.com/a/very/long/link/that/cannot/be/broken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapBody(tt.message, tt.width); got != tt.want {
+				t.Errorf("wrapBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendTrailer(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"", ""},
+		{"fix: x", "fix: x\n\nGenerated-by: test"},
+		{"fix: x\n\nWhy.", "fix: x\n\nWhy.\n\nGenerated-by: test"},
+		{"fix: x\n\nWhy.\n\nSigned-off-by: A <a@example.com>", "fix: x\n\nWhy.\n\nSigned-off-by: A <a@example.com>\nGenerated-by: test"},
+		{"fix: x\n\nCloses #12", "fix: x\n\nCloses #12\nGenerated-by: test"},
+		{"Refs: x", "Refs: x\n\nGenerated-by: test"},
+	}
+	for _, tt := range tests {
+		if got := appendTrailer(tt.message, "Generated-by: test"); got != tt.want {
+			t.Errorf("appendTrailer(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestSubjectStep(t *testing.T) {
+	cfg := &Config{PostProcessors: []string{"subject"}}
+	if got := finishMessage(cfg, "fix: typo  \n\nWhy.", nil, "", true, "m"); got != "fix: typo" {
+		t.Errorf("subject only = %q", got)
+	}
+	if got := finishMessage(cfg, "fix: typo\n\nWhy.", nil, "", false, "m"); got != "fix: typo\n\nWhy." {
+		t.Errorf("with a body = %q", got)
+	}
+}
+
+func TestPostProcessorsPipeline(t *testing.T) {
+	cfg := &Config{
+		Conventional:       true,
+		DisclaimerPatterns: defaultDisclaimerPatterns,
+		WrapWidth:          20,
+		GeneratedBy:        true,
+		ASCIIOnly:          true,
+		MaxSubjectLength:   40,
+		Bullets:            true,
+		ClosesIssue:        true,
+		Skeleton:           &Skeleton{},
+		OutputTemplate:     defaultOutputTemplate,
+	}
+	steps := postProcessors(cfg, []ScopeRule{{Name: "api"}}, "api", true, "m")
+	for _, name := range defaultPostProcessors {
+		if steps[name] == nil {
+			t.Errorf("no %s step with every setting on", name)
+		}
+	}
+	for name := range steps {
+		if !slices.Contains(defaultPostProcessors, name) {
+			t.Errorf("step %s isn't a default one", name)
+		}
+	}
+
+	// Off by default, the settings turn these on
+	steps = postProcessors(&Config{}, nil, "", false, "m")
+	for _, name := range []string{"subject", "scope", "length", "bullets", "ascii", "skeleton", "closes", "trailer", "template"} {
+		if steps[name] != nil {
+			t.Errorf("%s step without its setting", name)
+		}
+	}
+
+	message := "```\nfix: café\n\nThe quick brown fox jumps.\n\nHope this helps!\n```"
+	tests := []struct {
+		name  string
+		steps []string
+		want  string
+	}{
+		{"none", nil, message},
+		{"default order", defaultPostProcessors, "fix: cafe\n\nThe quick brown fox\njumps.\n\nGenerated-by: " + userAgent() + " model=m"},
+		{"disabled", []string{"clean", "disclaimers"}, "fix: café\n\nThe quick brown fox jumps."},
+		// Disclaimers are only stripped from the end, so not after the trailer
+		{"reordered", []string{"clean", "trailer", "disclaimers"}, "fix: café\n\nThe quick brown fox jumps.\n\nHope this helps!\n\nGenerated-by: " + userAgent() + " model=m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{DisclaimerPatterns: defaultDisclaimerPatterns, WrapWidth: 20, GeneratedBy: true, ASCIIOnly: true, PostProcessors: tt.steps}
+			if got := finishMessage(cfg, message, nil, "", false, "m"); got != tt.want {
+				t.Errorf("finishMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostProcessorsConfig(t *testing.T) {
+	path := isolate(t)
+	writeFile(t, path, `{"post_processors": ["clean", "wrap"]}`)
+	cfg, err := loadTestConfig(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.PostProcessors, []string{"clean", "wrap"}) {
+		t.Errorf("post_processors = %q", cfg.PostProcessors)
+	}
+
+	writeFile(t, path, `{"post_processors": ["clean", "emoji"]}`)
+	if _, err := loadTestConfig(t); err == nil || !strings.Contains(err.Error(), "emoji") {
+		t.Errorf("unknown post-processor: %v", err)
+	}
+}
+
+func TestNormalizeSpacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"subject only", "fix: typo", "fix: typo"},
+		{"already spaced", "fix: typo\n\nWhy.", "fix: typo\n\nWhy."},
+		{"missing blank line", "fix: typo\nWhy.", "fix: typo\n\nWhy."},
+		{"extra blank lines", "fix: typo\n\n\n\nWhy.", "fix: typo\n\nWhy."},
+		{"whitespace lines", "fix: typo\n  \n\t\nWhy.", "fix: typo\n\nWhy."},
+		{"paragraphs collapsed", "fix: typo\n\nFirst.\n\n\n\nSecond.", "fix: typo\n\nFirst.\n\nSecond."},
+		{"lines of a paragraph kept", "fix: typo\n\n- one\n- two", "fix: typo\n\n- one\n- two"},
+		{"surrounding blank lines", "\n\nfix: typo\n\nWhy.\n\n\n", "fix: typo\n\nWhy."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSpacing(tt.message); got != tt.want {
+				t.Errorf("normalizeSpacing() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpacingStep(t *testing.T) {
+	message := "fix: typo\nWhy.\n\n\nRefs: #1"
+	cfg := &Config{PostProcessors: slices.Clone(defaultPostProcessors)}
+	if got := finishMessage(cfg, message, nil, "", false, "m"); got != "fix: typo\n\nWhy.\n\nRefs: #1" {
+		t.Errorf("finishMessage() = %q", got)
+	}
+
+	cfg.PostProcessors = slices.DeleteFunc(cfg.PostProcessors, func(name string) bool { return name == "spacing" })
+	if got := finishMessage(cfg, message, nil, "", false, "m"); got != message {
+		t.Errorf("finishMessage() without spacing = %q", got)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed pr_prompt
+var prPrompt string
+
+// prMaxTokens leaves room for a multi-section Markdown description.
+const prMaxTokens = 1024
+
+// generatePRDescription produces a Markdown pull request body for the same
+// changes the commit message is generated from.
+func generatePRDescription(cfg *Config, diff, files, apiKey string) string {
+	fmt.Fprintln(os.Stderr, "🤖 Generating pull request description...")
+
+	description := complete(cfg, CompletionRequest{
+		Model:     cfg.modelFor(diff),
+		System:    strings.TrimSpace(prPrompt),
+		Prompt:    buildUserPrompt(cfg, diff, files),
+		MaxTokens: prMaxTokens,
+	}, apiKey)
+
+	return stripMarkdownFences(stripReasoning(description, cfg.ReasoningPatterns))
+}
@@ -0,0 +1,65 @@
+package main
+
+// ProviderPreset is an OpenAI-compatible API known by name, so selecting it
+// with --provider is all the setup it needs.
+type ProviderPreset struct {
+	BaseURL string
+	Model   string
+	KeyEnv  string
+
+	// Features are the optional request features the API accepts, see
+	// providerCapabilities.
+	Features []string
+}
+
+// providerPresets are the OpenAI-compatible providers, a new one only needs
+// an entry here.
+var providerPresets = map[string]ProviderPreset{
+	"groq": {
+		BaseURL:  "https://api.groq.com/openai/v1",
+		Model:    "llama-3.3-70b-versatile",
+		KeyEnv:   "GROQ_API_KEY",
+		Features: []string{featureStreaming, featureTools, featureJSON, featureSeed},
+	},
+	// Mistral calls the seed random_seed and rejects the OpenAI name
+	"mistral": {
+		BaseURL:  "https://api.mistral.ai/v1",
+		Model:    "mistral-small-latest",
+		KeyEnv:   "MISTRAL_API_KEY",
+		Features: []string{featureStreaming, featureTools, featureJSON},
+	},
+	"together": {
+		BaseURL:  "https://api.together.xyz/v1",
+		Model:    "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		KeyEnv:   "TOGETHER_API_KEY",
+		Features: []string{featureStreaming, featureTools, featureJSON, featureSeed},
+	},
+}
+
+// baseURL is the base URL of the OpenAI-compatible API, the preset's when
+// the provider is one.
+func (c *Config) baseURL() string {
+	if preset, ok := providerPresets[c.Provider]; ok {
+		return preset.BaseURL
+	}
+
+	return apiBaseURL
+}
+
+// keyEnv names the environment variable holding the provider's API key.
+func (c *Config) keyEnv() string {
+	if preset, ok := providerPresets[c.Provider]; ok {
+		return preset.KeyEnv
+	}
+
+	return providerKeyEnv[c.Provider]
+}
+
+// capabilities are the optional request features the provider supports.
+func (c *Config) capabilities() []string {
+	if preset, ok := providerPresets[c.Provider]; ok {
+		return preset.Features
+	}
+
+	return providerCapabilities[c.Provider]
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestPresetModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		model  string
+	}{
+		{"no config", "", []string{"--provider", "groq"}, "llama-3.3-70b-versatile"},
+		{"model flag", "", []string{"--provider", "mistral", "--model", "codestral-latest"}, "codestral-latest"},
+		{"provider in config", `{"provider": "together"}`, nil, "meta-llama/Llama-3.3-70B-Instruct-Turbo"},
+		{"model with the provider", `{"provider": "groq", "model": "qwen-qwq-32b"}`, nil, "qwen-qwq-32b"},
+		{"model for another provider", `{"model": "gemini-2.5-pro"}`, []string{"--provider", "groq"}, "llama-3.3-70b-versatile"},
+		{"Gemini default pinned with the provider", `{"provider": "groq", "model": "gemini-2.0-flash"}`, nil, "llama-3.3-70b-versatile"},
+		{"not a preset", `{"model": "gemini-2.5-pro"}`, []string{"--provider", "openai"}, "gemini-2.5-pro"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := isolate(t)
+			if tt.config != "" {
+				writeFile(t, path, tt.config)
+			}
+
+			cfg, err := loadTestConfig(t, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Model != tt.model {
+				t.Errorf("model = %q, want %q", cfg.Model, tt.model)
+			}
+		})
+	}
+}
+
+func TestPresetModelAfterInit(t *testing.T) {
+	isolate(t)
+	if err := initCmd.Run(context.Background(), []string{"init"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadTestConfig(t, "--provider", "groq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Model != "llama-3.3-70b-versatile" {
+		t.Errorf("model = %q after init, want the groq preset's", cfg.Model)
+	}
+	if cfg.baseURL() != "https://api.groq.com/openai/v1" {
+		t.Errorf("baseURL() = %q", cfg.baseURL())
+	}
+	if cfg.keyEnv() != "GROQ_API_KEY" {
+		t.Errorf("keyEnv() = %q", cfg.keyEnv())
+	}
+}
+
+func TestPresetResolution(t *testing.T) {
+	for name, preset := range providerPresets {
+		t.Run(name, func(t *testing.T) {
+			cfg := &Config{Provider: name}
+			if err := cfg.validate(); err != nil && err.Error() == `unknown provider "`+name+`"` {
+				t.Fatalf("preset %s isn't a known provider", name)
+			}
+			if cfg.baseURL() != preset.BaseURL || cfg.keyEnv() != preset.KeyEnv {
+				t.Errorf("got %s and %s, want the preset's", cfg.baseURL(), cfg.keyEnv())
+			}
+			if !slices.Equal(cfg.capabilities(), preset.Features) {
+				t.Errorf("capabilities() = %q, want %q", cfg.capabilities(), preset.Features)
+			}
+		})
+	}
+
+	cfg := &Config{Provider: providerOpenAI}
+	if cfg.baseURL() != apiBaseURL || cfg.keyEnv() != "GEMINI_API_KEY" {
+		t.Errorf("openai got %s and %s", cfg.baseURL(), cfg.keyEnv())
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// profileProblems checks a profile applied on top of the config files: that
+// it parses, that the resulting settings are valid, the prompt file exists
+// and is a valid template and a model is set. It returns what's wrong, if
+// anything.
+func profileProblems(name string) []string {
+	// Fresh for every profile, applying one changes the maps and slices
+	cfg, err := loadConfigFiles()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if err := json.Unmarshal(cfg.Profiles[name], cfg); err != nil {
+		return []string{fmt.Sprintf("failed to parse: %s", err)}
+	}
+
+	var problems []string
+	if err := cfg.validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	// A prompt URL falls back to a working prompt, it can't break the profile
+	if cfg.PromptFile != "" && !isPromptURL(cfg.PromptFile) {
+		content, err := os.ReadFile(expandHome(cfg.PromptFile))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("failed to read prompt file: %s", err))
+		} else if _, err := template.New("systemprompt").Parse(string(content)); err != nil {
+			problems = append(problems, fmt.Sprintf("failed to parse prompt file %s: %s", cfg.PromptFile, err))
+		}
+	}
+
+	for _, model := range []string{cfg.Model, cfg.SmallModel, cfg.LargeModel} {
+		if alias, ok := cfg.ModelAliases[model]; ok {
+			model = alias
+		}
+		if strings.ContainsAny(model, " \t") {
+			problems = append(problems, fmt.Sprintf("invalid model name %q", model))
+		}
+	}
+	if strings.TrimSpace(cfg.Model) == "" {
+		problems = append(problems, "no model set")
+	}
+
+	return problems
+}
+
+// listProfiles prints every configured profile along with its problems,
+// and fails when any has problems.
+func listProfiles() error {
+	cfg, err := loadConfigFiles()
+	if err != nil {
+		return err
+	}
+	if len(cfg.Profiles) == 0 {
+		fmt.Fprintln(os.Stderr, "⚠️ No profiles configured")
+		return nil
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	broken := 0
+	for _, name := range names {
+		problems := profileProblems(name)
+		if len(problems) == 0 {
+			fmt.Printf("✅ %s\n", name)
+			continue
+		}
+
+		broken++
+		fmt.Printf("❌ %s\n", name)
+		for _, problem := range problems {
+			fmt.Printf("   %s\n", problem)
+		}
+	}
+	if broken > 0 {
+		return fmt.Errorf("Error: %d of %d profiles have problems", broken, len(names))
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProfileProblemsDontLeakIntoOtherProfiles(t *testing.T) {
+	writeFile(t, isolate(t), `{
+		"profiles": {
+			"a": {"entropy_allowlist": ["("], "post_processors": ["wrap"]},
+			"b": {"language": "English"}
+		}
+	}`)
+
+	if problems := profileProblems("a"); len(problems) == 0 {
+		t.Fatal("profile a has an invalid regexp but no problems")
+	}
+	if problems := profileProblems("b"); len(problems) != 0 {
+		t.Errorf("profile b is valid, got %q", problems)
+	}
+
+	if !slices.Equal(defaultEntropyAllowlist, []string{`^[0-9a-f]{7,64}$`}) {
+		t.Errorf("defaultEntropyAllowlist changed to %q", defaultEntropyAllowlist)
+	}
+	if defaultPostProcessors[0] != "clean" {
+		t.Errorf("defaultPostProcessors changed to %q", defaultPostProcessors)
+	}
+}
+
+func TestProfileProblems(t *testing.T) {
+	writeFile(t, isolate(t), `{
+		"profiles": {
+			"ok": {"model": "gemini-2.0-flash"},
+			"provider": {"provider": "nope"},
+			"model": {"model": "not a model"},
+			"prompt": {"prompt_file": "/does/not/exist"}
+		}
+	}`)
+
+	tests := []struct {
+		profile string
+		broken  bool
+	}{
+		{"ok", false},
+		{"provider", true},
+		{"model", true},
+		{"prompt", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			problems := profileProblems(tt.profile)
+			if broken := len(problems) > 0; broken != tt.broken {
+				t.Errorf("profileProblems(%q) = %q, want broken %v", tt.profile, problems, tt.broken)
+			}
+		})
+	}
+}
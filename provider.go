@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultTemperature leaves a little room for phrasing variety.
+const defaultTemperature = 0.3
+
+// retryTemperatureBoost is added to the temperature for each retry after an
+// empty response.
+const retryTemperatureBoost = 0.2
+
+const (
+	providerOpenAI       = "openai"
+	providerGeminiNative = "gemini-native"
+)
+
+// CompletionRequest describes a single prompt to send to the configured
+// provider, independent of the provider's wire format.
+type CompletionRequest struct {
+	Model     string
+	System    string
+	Prompt    string
+	MaxTokens int
+
+	// Structured asks for the commit message as separate parts where the
+	// provider supports it, instead of free text.
+	Structured bool
+
+	// FirstLineOnly stops as soon as the first line is complete.
+	FirstLineOnly bool
+
+	// JSON asks the provider to respond with a JSON object, the prompt
+	// describes its fields.
+	JSON bool
+
+	// TemperatureBoost is added to the configured temperature, to shake
+	// things up when retrying.
+	TemperatureBoost float64
+}
+
+// temperature is zero in deterministic mode, so a fixed seed yields the same
+// message for the same diff.
+func (c *Config) temperature() float64 {
+	if c.Seed != nil {
+		return 0
+	}
+
+	return defaultTemperature
+}
+
+// developerRoleModels are the model families that expect the system prompt
+// in a developer message rather than a system one.
+var developerRoleModels = []string{"o1", "o3", "o4"}
+
+// systemRole is the role of the message carrying the system prompt for the
+// OpenAI-compatible API, the configured one or a default for the model.
+func (c *Config) systemRole(model string) string {
+	if c.SystemRole != "" {
+		return c.SystemRole
+	}
+
+	for _, family := range developerRoleModels {
+		if model == family || strings.HasPrefix(model, family+"-") {
+			return "developer"
+		}
+	}
+
+	return "system"
+}
+
+// modelFor picks the model for a diff, switching between the small and the
+// large model around the configured threshold when they are set.
+func (c *Config) modelFor(diff string) string {
+	chosen := c.Model
+	if chosen == "" {
+		chosen = defaultModel
+	}
+	if c.ModelSwitchLines > 0 {
+		if countChangedLines(diff) < c.ModelSwitchLines {
+			if c.SmallModel != "" {
+				chosen = c.SmallModel
+			}
+		} else if c.LargeModel != "" {
+			chosen = c.LargeModel
+		}
+	}
+
+	if alias, ok := c.ModelAliases[chosen]; ok {
+		chosen = alias
+	}
+
+	logVerbose(c, "🤖 Using model %s", chosen)
+	return chosen
+}
+
+// complete sends the request to the configured provider and returns the raw
+// text of the response, or an empty string after reporting a failure.
+func complete(cfg *Config, completion CompletionRequest, apiKey string) string {
+	text, _ := completeAnswered(cfg, completion, apiKey)
+	return text
+}
+
+// completeAnswered is complete, also reporting whether the provider answered.
+// It's false after a reported failure, like a rejected key, an exceeded budget
+// or a blocked prompt, which asking again can't fix.
+func completeAnswered(cfg *Config, completion CompletionRequest, apiKey string) (string, bool) {
+	completion = adaptCompletion(cfg, completion)
+	key := cacheKey(cfg, completion)
+	if cfg.Cache {
+		if text, ok := readCache(key); ok {
+			report.addCached()
+			fmt.Fprintln(os.Stderr, "💾 Using cached response")
+			return text, true
+		}
+	}
+
+	if !checkBudget(cfg, completion) {
+		return "", false
+	}
+
+	var text string
+	var answered bool
+	start := time.Now()
+	switch cfg.Provider {
+	case providerGeminiNative:
+		text, answered = completeWithGeminiNative(cfg, completion, apiKey)
+	default:
+		text, answered = completeWithOpenAI(cfg, completion, apiKey)
+	}
+	report.addRequest(completion.Model, time.Since(start))
+
+	if cfg.Cache && strings.TrimSpace(text) != "" {
+		writeCache(key, text)
+	}
+
+	return text, answered
+}
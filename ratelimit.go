@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultConcurrency bounds how many requests batch features like candidates
+// have in flight at once.
+const defaultConcurrency = 3
+
+// rateLimiter is a token bucket holding up to a second's worth of requests,
+// shared by every request to the model API.
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+var requestLimiter rateLimiter
+
+// waitForRequest blocks until another request fits within the configured
+// requests per second, right away when there is no limit.
+func waitForRequest(cfg *Config) {
+	if cfg.RPS <= 0 {
+		return
+	}
+
+	if delay := requestLimiter.take(cfg.RPS, time.Now()); delay > 0 {
+		logVerbose(cfg, "⏳ Waiting %s for the rate limit", delay.Round(time.Millisecond))
+		time.Sleep(delay)
+	}
+}
+
+// take reserves a token at the given rate and returns how long to wait
+// before it can be used, waiters queue up in the order they arrive.
+func (l *rateLimiter) take(rps float64, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacity := max(1, float64(int(rps)))
+	if l.last.IsZero() {
+		l.tokens = capacity
+		l.last = now
+	} else if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(capacity, l.tokens+elapsed.Seconds()*rps)
+		l.last = now
+	}
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / rps * float64(time.Second))
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// defaultReasoningPatterns match the blocks reasoning models use to think out
+// loud before answering.
+var defaultReasoningPatterns = []string{
+	`(?is)<think>.*?</think>`,
+	`(?is)<thinking>.*?</thinking>`,
+	`(?is)<reasoning>.*?</reasoning>`,
+	`(?is)<reflection>.*?</reflection>`,
+}
+
+// stripReasoning removes every block matching one of the patterns, wherever
+// it appears in the response.
+func stripReasoning(message string, patterns []string) string {
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			message = re.ReplaceAllString(message, "")
+		}
+	}
+
+	return strings.TrimSpace(message)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+var regenerateCmd = &cli.Command{
+	Name:      "regenerate",
+	Usage:     "Regenerate the message in a commit message file, optionally keeping its subject or body",
+	ArgsUsage: "commit-msg-file",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "body-only",
+			Usage: "keep the existing subject and only regenerate the body",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Args().Len() < 1 {
+			return fmt.Errorf("Error: No commit message file provided")
+		}
+		commitMsgFile := cmd.Args().First()
+
+		if err := requireGit(); err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if cfg.Report {
+			defer report.print()
+		}
+
+		bodyOnly := cmd.Bool("body-only")
+		if bodyOnly && cfg.SubjectOnly {
+			return fmt.Errorf("Error: --subject-only and --body-only can't be combined")
+		}
+
+		apiKey, source := resolveAPIKey(cfg)
+		if apiKey == "" {
+			return missingAPIKeyError(cfg)
+		}
+		if cfg.ValidateKey && !keyWorks(cfg, apiKey, source) {
+			return fmt.Errorf("Error: Invalid API key")
+		}
+
+		content, err := os.ReadFile(commitMsgFile)
+		if err != nil {
+			return fmt.Errorf("Error reading commit message file: %w", err)
+		}
+		subject, body, comments := splitCommitMessage(string(content))
+
+		diff, changedFiles, err := collectChanges(cfg, apiKey)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			return fmt.Errorf("Error: No staged changes")
+		}
+
+		switch {
+		case cfg.SubjectOnly && body != "":
+			cfg.ExtraInstructions = append(cfg.ExtraInstructions,
+				"The body of the commit message is already written, write a subject line that fits it:\n"+body)
+		case bodyOnly && subject != "":
+			cfg.ExtraInstructions = append(cfg.ExtraInstructions,
+				"Keep this exact subject line and write the body for it:\n"+subject)
+		}
+
+		message := generateCommitMessage(cfg, diff, changedFiles, apiKey)
+		if message == "" {
+			return fmt.Errorf("Error: No message generated")
+		}
+		if err := checkMessage(cfg, message); err != nil {
+			return err
+		}
+
+		// Put the kept part back verbatim, whatever the model did with it
+		switch {
+		case cfg.SubjectOnly && body != "":
+			message = message + "\n\n" + body
+		case bodyOnly && subject != "":
+			_, newBody, _ := strings.Cut(message, "\n")
+			message = subject + "\n\n" + strings.TrimSpace(newBody)
+		}
+
+		newContent := message + "\n"
+		if comments != "" {
+			newContent += "\n" + comments
+		}
+		newContent = matchLineEndings(newContent, string(content))
+		if err := writeCommitMessageFile(commitMsgFile, content, newContent); err != nil {
+			return fmt.Errorf("Error writing commit message file: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "✅ Commit message regenerated in %s\n", commitMsgFile)
+		return nil
+	},
+}
+
+// splitCommitMessage separates a commit message file into the subject (the
+// first paragraph), the body and git's comment lines.
+func splitCommitMessage(content string) (string, string, string) {
+	var message, comments []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			comments = append(comments, line)
+		} else {
+			message = append(message, line)
+		}
+	}
+
+	text := strings.TrimSpace(strings.Join(message, "\n"))
+	subject, body, _ := strings.Cut(text, "\n\n")
+
+	commentText := strings.Join(comments, "\n")
+	if commentText != "" {
+		commentText += "\n"
+	}
+
+	return strings.TrimSpace(subject), strings.TrimSpace(body), commentText
+}
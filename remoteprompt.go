@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// promptFetchTimeout keeps a slow server from holding up the commit.
+	promptFetchTimeout = 5 * time.Second
+	// maxPromptSize is far beyond any prompt, a larger response is a mistake.
+	maxPromptSize = 1 << 20
+)
+
+// isPromptURL reports whether the prompt file is a URL to fetch rather than
+// a local path.
+func isPromptURL(promptFile string) bool {
+	return strings.HasPrefix(promptFile, "https://") || strings.HasPrefix(promptFile, "http://")
+}
+
+// fetchPrompt returns the prompt template served at the URL. The last copy is
+// cached along with its ETag, so an unchanged prompt costs a 304 response.
+// When the prompt can't be fetched, or isn't a valid template, the cached
+// copy is used instead, and without one the built-in prompt, so being offline
+// never blocks a commit.
+func fetchPrompt(cfg *Config, promptURL string) string {
+	sum := sha256.Sum256([]byte(promptURL))
+	key := "prompt-" + hex.EncodeToString(sum[:])
+	cached, hasCached := readCache(key)
+
+	fallback := func(reason string) string {
+		if hasCached {
+			fmt.Fprintf(os.Stderr, "⚠️ Couldn't fetch the prompt from %s, using the cached copy: %s\n", promptURL, reason)
+			return cached
+		}
+		fmt.Fprintf(os.Stderr, "⚠️ Couldn't fetch the prompt from %s, using the built-in prompt: %s\n", promptURL, reason)
+		return systemPrompt
+	}
+
+	req, err := http.NewRequest("GET", promptURL, nil)
+	if err != nil {
+		return fallback(err.Error())
+	}
+	req.Header.Set("User-Agent", userAgent())
+	if etag, ok := readCache(key + ".etag"); ok && hasCached && etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	client := &http.Client{Timeout: promptFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fallback(err.Error())
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && hasCached:
+		logVerbose(cfg, "📄 The prompt at %s is unchanged", promptURL)
+		return cached
+	case resp.StatusCode != http.StatusOK:
+		return fallback(fmt.Sprintf("status %d", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPromptSize+1))
+	if err != nil {
+		return fallback(err.Error())
+	}
+	if len(body) > maxPromptSize {
+		return fallback(fmt.Sprintf("larger than %d bytes", maxPromptSize))
+	}
+	// A broken template mustn't replace a working copy
+	if _, err := template.New("systemprompt").Parse(string(body)); err != nil {
+		return fallback(fmt.Sprintf("invalid template: %s", err))
+	}
+
+	logVerbose(cfg, "📄 Fetched the prompt from %s", promptURL)
+	writeCache(key, string(body))
+	writeCache(key+".etag", resp.Header.Get("ETag"))
+
+	return string(body)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// runReport adds up the requests of a run for --report. Requests may run
+// concurrently, so it's guarded by a mutex.
+type runReport struct {
+	mu sync.Mutex
+
+	requests         int
+	cached           int
+	promptTokens     int
+	completionTokens int
+	latency          time.Duration
+	models           []string
+	cost             float64
+	priced           bool
+	unpriced         bool
+	truncations      []string
+}
+
+// report collects the figures of the current run.
+var report = &runReport{}
+
+// addRequest records a request sent to the model and how long it took.
+func (r *runReport) addRequest(model string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests++
+	r.latency += latency
+	if !slices.Contains(r.models, model) {
+		r.models = append(r.models, model)
+	}
+}
+
+// addCached records a response served from the cache.
+func (r *runReport) addCached() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cached++
+}
+
+// addUsage records the tokens a response used, and their cost when the model
+// has a price.
+func (r *runReport) addUsage(cfg *Config, model string, inputTokens, outputTokens int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.promptTokens += inputTokens
+	r.completionTokens += outputTokens
+	if price, ok := cfg.Prices[model]; ok {
+		r.cost += price.cost(inputTokens, outputTokens)
+		r.priced = true
+	} else {
+		r.unpriced = true
+	}
+}
+
+// addTruncation records something that was cut to fit.
+func (r *runReport) addTruncation(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.truncations = append(r.truncations, fmt.Sprintf(format, args...))
+}
+
+// print writes the report as a single block to stderr.
+func (r *runReport) print() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	models := strings.Join(r.models, ", ")
+	if models == "" {
+		models = "-"
+	}
+	requests := fmt.Sprint(r.requests)
+	if r.cached > 0 {
+		requests += fmt.Sprintf(" (+%d cached)", r.cached)
+	}
+	latency := "-"
+	if r.requests > 0 {
+		latency = fmt.Sprintf("%s total, %s per request", r.latency.Round(time.Millisecond), (r.latency / time.Duration(r.requests)).Round(time.Millisecond))
+	}
+	cost := "-"
+	switch {
+	case r.priced && r.unpriced:
+		cost = fmt.Sprintf("$%.6f, without models that have no price configured", r.cost)
+	case r.priced:
+		cost = fmt.Sprintf("$%.6f", r.cost)
+	case r.unpriced:
+		cost = "unknown, no price configured"
+	}
+	truncations := strings.Join(r.truncations, "; ")
+	if truncations == "" {
+		truncations = "none"
+	}
+
+	fmt.Fprintln(os.Stderr, "📊 Report")
+	out := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(out, "   Model\t%s\n", models)
+	fmt.Fprintf(out, "   Requests\t%s\n", requests)
+	fmt.Fprintf(out, "   Tokens\t%d prompt + %d completion\n", r.promptTokens, r.completionTokens)
+	fmt.Fprintf(out, "   Latency\t%s\n", latency)
+	fmt.Fprintf(out, "   Cost\t%s\n", cost)
+	fmt.Fprintf(out, "   Truncation\t%s\n", truncations)
+	out.Flush()
+}
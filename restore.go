@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// commitBackupName is the file in the git dir holding the last draft the
+// hook overwrote.
+const commitBackupName = "COMMITMENT_EDITMSG.bak"
+
+var restoreCmd = &cli.Command{
+	Name:  "restore",
+	Usage: "Print the last commit message draft saved before it was overwritten",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "write the draft to `FILE`, - for stdout",
+			Value: "-",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if err := requireGit(); err != nil {
+			return err
+		}
+
+		backupPath, err := commitBackupPath()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(backupPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("Error: No commit message backup")
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read commit message backup: %w", err)
+		}
+
+		// Git's comments were only there for the editor
+		subject, body, _ := splitCommitMessage(string(content))
+		draft := subject
+		if body != "" {
+			draft += "\n\n" + body
+		}
+
+		if err := writeOutput(cmd.String("output"), draft); err != nil {
+			return fmt.Errorf("Failed to write commit message draft: %w", err)
+		}
+
+		return nil
+	},
+}
+
+// commitBackupPath is where the draft is saved, in the git dir of the
+// current worktree.
+func commitBackupPath() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", commitBackupName).Output()
+	if err != nil {
+		return "", fmt.Errorf("Failed to find the git directory: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// writeCommitMessageFile replaces the content of the commit message file.
+// A draft in the original content is saved to the backup first, and the
+// original is put back when the write fails, so a half written file never
+// costs the user their words.
+func writeCommitMessageFile(commitMsgFile string, original []byte, content string) error {
+	if subject, body, _ := splitCommitMessage(string(original)); subject != "" || body != "" {
+		if err := backupCommitMessage(original); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Couldn't back up the commit message: %s\n", err)
+		}
+	}
+
+	if err := os.WriteFile(commitMsgFile, []byte(content), 0644); err != nil {
+		if restoreErr := os.WriteFile(commitMsgFile, original, 0644); restoreErr != nil {
+			fmt.Fprintln(os.Stderr, "⚠️ Couldn't put the commit message back, recover it with commitment restore")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// backupCommitMessage saves the content of the commit message file, replacing
+// the previous backup.
+func backupCommitMessage(content []byte) error {
+	backupPath, err := commitBackupPath()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(backupPath, content, 0644)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// revertWindow is how many recent commits are checked for being undone by
+// the staged changes.
+const revertWindow = 50
+
+// revertMessage is the message git revert writes.
+func revertMessage(commit, subject string) string {
+	return fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.", subject, commit)
+}
+
+// detectedRevertMessage is the revert message for the commit the changes of
+// the diff target undo, or an empty string when they don't undo one or
+// --detect-reverts is off.
+func detectedRevertMessage(cfg *Config) string {
+	if !cfg.DetectReverts {
+		return ""
+	}
+
+	commit, subject := revertedCommit(cfg.DiffTarget)
+	if commit == "" {
+		return ""
+	}
+
+	fmt.Fprintf(os.Stderr, "↩️ The changes revert %s, using its revert message\n", shortCommit(commit))
+	return revertMessage(commit, subject)
+}
+
+// revertedCommit finds the recent commit the changes of the diff target
+// exactly undo, by comparing the patch ID of the reversed diff with theirs.
+// Only the commits touching the same files are diffed, most changes share
+// them with none. Both are empty when there is none.
+func revertedCommit(target string) (string, string) {
+	candidates := sameFileCommits(target)
+	if len(candidates) == 0 {
+		return "", ""
+	}
+
+	// Without prefixes, since reversing swaps them and they're part of the ID
+	args := append(append([]string{"diff"}, diffTargetArgs(target)...), "-R", "--no-prefix")
+	reversed, err := exec.Command("git", args...).Output()
+	if err != nil || len(reversed) == 0 {
+		return "", ""
+	}
+	staged := patchID(string(reversed))
+	if len(staged) == 0 {
+		return "", ""
+	}
+
+	args = append([]string{"log", "-p", "--no-prefix", "--no-walk"}, candidates...)
+	recent, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", ""
+	}
+	for _, line := range strings.Split(strings.TrimSpace(runPatchID(string(recent))), "\n") {
+		id, commit, found := strings.Cut(line, " ")
+		if found && id == staged {
+			return commit, commitSubject(commit)
+		}
+	}
+
+	return "", ""
+}
+
+// sameFileCommits lists the recent commits that touched exactly the files
+// the changes of the diff target touch, the only ones they can revert.
+func sameFileCommits(target string) []string {
+	args := append(append([]string{"diff"}, diffTargetArgs(target)...), "--name-only", "--no-renames")
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil
+	}
+	changed := strings.Fields(string(output))
+	if len(changed) == 0 {
+		return nil
+	}
+	slices.Sort(changed)
+
+	output, err = exec.Command("git", "log", "--no-merges", "--no-renames", "--name-only", "--format=%x00%H",
+		"-n", fmt.Sprint(revertWindow)).Output()
+	if err != nil {
+		return nil
+	}
+	var commits []string
+	for _, entry := range strings.Split(string(output), "\x00")[1:] {
+		commit, files, _ := strings.Cut(entry, "\n")
+		touched := strings.Fields(files)
+		slices.Sort(touched)
+		if slices.Equal(touched, changed) {
+			commits = append(commits, commit)
+		}
+	}
+
+	return commits
+}
+
+// patchID is the stable patch ID of a single diff.
+func patchID(diff string) string {
+	id, _, _ := strings.Cut(runPatchID(diff), " ")
+	return id
+}
+
+// runPatchID runs git patch-id on the input, one "<patch-id> <commit>"
+// line for each diff in it.
+func runPatchID(input string) string {
+	cmd := exec.Command("git", "patch-id", "--stable")
+	cmd.Stdin = strings.NewReader(input)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return string(output)
+}
+
+// revertDraftMessage completes a draft that only says it's a revert, like
+// `git commit -m 'Revert "Add caching"'` or just `Revert`, into git's revert
+// message. It returns an empty string when the draft is anything else or the
+// reverted commit can't be found.
+func revertDraftMessage(commitMsgFile string) string {
+	content, err := os.ReadFile(commitMsgFile)
+	if err != nil {
+		return ""
+	}
+
+	subject, body, _ := splitCommitMessage(string(content))
+	if body != "" || !strings.HasPrefix(subject, "Revert") {
+		return ""
+	}
+
+	// The draft names the commit, look it up by its subject
+	if quoted, ok := strings.CutPrefix(subject, "Revert \""); ok && strings.HasSuffix(quoted, "\"") {
+		original := strings.TrimSuffix(quoted, "\"")
+		if commit := commitBySubject(original); commit != "" {
+			return revertMessage(commit, original)
+		}
+	}
+
+	if commit, original := revertedCommit(diffTargetStaged); commit != "" {
+		return revertMessage(commit, original)
+	}
+
+	return ""
+}
+
+// commitBySubject finds the most recent commit with the given subject.
+func commitBySubject(subject string) string {
+	output, err := exec.Command("git", "log", "-n", fmt.Sprint(revertWindow), "--format=%H%x00%s").Output()
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if commit, entrySubject, found := strings.Cut(line, "\x00"); found && entrySubject == subject {
+			return commit
+		}
+	}
+
+	return ""
+}
+
+// commitSubject is the subject line of the commit.
+func commitSubject(commit string) string {
+	output, err := exec.Command("git", "log", "-n", "1", "--format=%s", commit).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// revertRepo has a few commits, the one before the last adding caching, and
+// stages the given content of the file that commit added, or its removal when
+// it's empty. It returns the commit adding caching.
+func revertRepo(t *testing.T, staged string) string {
+	t.Helper()
+
+	initRepo(t)
+	commitFile(t, "main.go", "package main\n", "feat: add main")
+	commitFile(t, "cache.go", "package main\n\nvar cache = map[string]string{}\n", "feat: add caching")
+	commitFile(t, "README.md", "# Test\n", "docs: add readme")
+	caching := strings.TrimSpace(runGit(t, "rev-parse", "HEAD~1"))
+	if staged == "" {
+		runGit(t, "rm", "-q", "cache.go")
+	} else {
+		writeFile(t, "cache.go", staged)
+		runGit(t, "add", "cache.go")
+	}
+
+	return caching
+}
+
+func TestDetectedRevertMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		staged  string
+		detect  bool
+		reverts bool
+	}{
+		{"undoes the commit", "", true, true},
+		{"off", "", false, false},
+		{"changes something else", "package main\n\nvar cache = map[string]int{}\n", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			caching := revertRepo(t, tt.staged)
+
+			got := detectedRevertMessage(&Config{DiffTarget: diffTargetStaged, DetectReverts: tt.detect})
+			want := ""
+			if tt.reverts {
+				want = "Revert \"feat: add caching\"\n\nThis reverts commit " + caching + "."
+			}
+			if got != want {
+				t.Errorf("detectedRevertMessage() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRevertDraftMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		draft string
+		want  bool
+	}{
+		{"subject of a recent commit", "Revert \"feat: add caching\"\n", true},
+		{"just revert", "Revert\n", true},
+		{"with a body", "Revert\n\nIt broke the build.\n", false},
+		{"anything else", "fix: handle nil\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			dir := t.TempDir()
+			caching := revertRepo(t, "")
+			path := filepath.Join(dir, "COMMIT_EDITMSG")
+			writeFile(t, path, tt.draft)
+
+			got := revertDraftMessage(path)
+			want := ""
+			if tt.want {
+				want = "Revert \"feat: add caching\"\n\nThis reverts commit " + caching + "."
+			}
+			if got != want {
+				t.Errorf("revertDraftMessage() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDetectRevertsConfig(t *testing.T) {
+	tests := []struct {
+		config string
+		args   []string
+		want   bool
+	}{
+		{"", nil, true},
+		{`{"detect_reverts": false}`, nil, false},
+		{`{"detect_reverts": false}`, []string{"--detect-reverts"}, true},
+		{"", []string{"--detect-reverts=false"}, false},
+	}
+	for _, tt := range tests {
+		path := isolate(t)
+		if tt.config != "" {
+			writeFile(t, path, tt.config)
+		}
+
+		cfg, err := loadTestConfig(t, tt.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.DetectReverts != tt.want {
+			t.Errorf("config %q with %q: DetectReverts = %v, want %v", tt.config, tt.args, cfg.DetectReverts, tt.want)
+		}
+	}
+}
+
+func TestSameFileCommits(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage func(t *testing.T)
+		want  bool
+	}{
+		{"the same file", func(t *testing.T) {}, true},
+		{"another file too", func(t *testing.T) {
+			writeFile(t, "README.md", "# Other\n")
+			runGit(t, "add", "README.md")
+		}, false},
+		{"nothing staged", func(t *testing.T) { runGit(t, "reset", "-q") }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			caching := revertRepo(t, "")
+			tt.stage(t)
+
+			got := sameFileCommits(diffTargetStaged)
+			if tt.want && (len(got) != 1 || got[0] != caching) {
+				t.Errorf("sameFileCommits() = %q, want the commit adding caching", got)
+			}
+			if !tt.want && len(got) != 0 {
+				t.Errorf("sameFileCommits() = %q, want none", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ScopeRule declares an allowed Conventional Commits scope and the paths
+// belonging to it. Without paths, any path with a directory named like the
+// scope matches.
+type ScopeRule struct {
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"`
+}
+
+var reConventionalHeader = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?: (.*)$`)
+
+// dominantScope maps the changed paths to scopes and returns the scope owning
+// the majority of them, or an empty string when no scope clearly dominates.
+func dominantScope(rules []ScopeRule, paths []string) string {
+	counts := map[string]int{}
+	for _, path := range paths {
+		for _, rule := range rules {
+			if rule.matches(path) {
+				counts[rule.Name]++
+				break
+			}
+		}
+	}
+
+	for name, count := range counts {
+		if count*2 > len(paths) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func (r ScopeRule) matches(path string) bool {
+	if len(r.Paths) == 0 {
+		dirs := strings.Split(path, "/")
+		return slices.Contains(dirs[:len(dirs)-1], r.Name)
+	}
+
+	for _, prefix := range r.Paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// genericDirs are too common to say anything about a change as a scope.
+var genericDirs = []string{"src", "lib", "libs", "internal", "pkg", "app", "apps", "cmd", "packages", "modules", "services"}
+
+// directoryScope derives a scope from the deepest directory all paths share,
+// e.g. "auth" for changes within internal/auth/. Without a telling common
+// directory, the top-level one holding the majority of the paths is used,
+// if any.
+func directoryScope(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	common := strings.Split(path.Dir(paths[0]), "/")
+	counts := map[string]int{}
+	for _, p := range paths {
+		dirs := strings.Split(path.Dir(p), "/")
+		n := 0
+		for n < len(common) && n < len(dirs) && common[n] == dirs[n] {
+			n++
+		}
+		common = common[:n]
+		if dirs[0] != "." {
+			counts[dirs[0]]++
+		}
+	}
+
+	for i := len(common) - 1; i >= 0; i-- {
+		if common[i] != "." && !slices.Contains(genericDirs, common[i]) {
+			return common[i]
+		}
+	}
+
+	for dir, count := range counts {
+		if count*2 > len(paths) && !slices.Contains(genericDirs, dir) {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+// scopeNames lists the allowed scopes in config order.
+func scopeNames(rules []ScopeRule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+
+	return names
+}
+
+// scopeInstruction tells the model which scope to use for this change.
+func scopeInstruction(rules []ScopeRule, scope string) string {
+	if scope == "" {
+		return fmt.Sprintf("Do not use a scope. The only valid scopes are: %s, and this change doesn't belong to a single one.", strings.Join(scopeNames(rules), ", "))
+	}
+
+	return fmt.Sprintf("Use the scope `%s`. The only valid scopes are: %s.", scope, strings.Join(scopeNames(rules), ", "))
+}
+
+// enforceScope rewrites the scope of a Conventional Commits subject that uses
+// anything other than the allowed scopes, replacing it with the resolved one.
+func enforceScope(message string, rules []ScopeRule, scope string) string {
+	subject, rest, hasBody := strings.Cut(message, "\n")
+	matches := reConventionalHeader.FindStringSubmatch(subject)
+	if matches == nil {
+		return message
+	}
+
+	if matches[2] == "" || slices.Contains(scopeNames(rules), matches[2]) {
+		return message
+	}
+
+	header := matches[1]
+	if scope != "" {
+		header += "(" + scope + ")"
+	}
+	subject = header + matches[3] + ": " + matches[4]
+
+	if hasBody {
+		return subject + "\n" + rest
+	}
+
+	return subject
+}
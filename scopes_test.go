@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDirectoryScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"root files", []string{"main.go", "go.mod"}, ""},
+		{"common directory", []string{"internal/auth/login.go", "internal/auth/token.go"}, "auth"},
+		{"deepest telling directory", []string{"internal/auth/oauth/github.go", "internal/auth/oauth/google.go"}, "oauth"},
+		{"shared parent", []string{"internal/auth/oauth/github.go", "internal/auth/session.go"}, "auth"},
+		{"generic directories only", []string{"src/main.go", "src/util.go"}, ""},
+		{"dominant top-level directory", []string{"docs/a.md", "docs/b.md", "web/index.html"}, "docs"},
+		{"no dominant directory", []string{"docs/a.md", "web/index.html"}, ""},
+		{"generic dominant directory", []string{"pkg/a/a.go", "pkg/b/b.go", "web/index.html"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := directoryScope(tt.paths); got != tt.want {
+				t.Errorf("directoryScope(%q) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDominantScope(t *testing.T) {
+	rules := []ScopeRule{
+		{Name: "api", Paths: []string{"server/handlers/", "openapi.yaml"}},
+		{Name: "ui", Paths: []string{"web/"}},
+		{Name: "auth"},
+	}
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"path prefix", []string{"server/handlers/users.go"}, "api"},
+		{"file", []string{"openapi.yaml", "server/handlers/users.go"}, "api"},
+		{"directory named like the scope", []string{"internal/auth/token.go"}, "auth"},
+		{"file named like the scope", []string{"auth"}, ""},
+		{"majority", []string{"web/a.ts", "web/b.ts", "server/handlers/users.go"}, "ui"},
+		{"no majority", []string{"web/a.ts", "server/handlers/users.go"}, ""},
+		{"unmapped paths count", []string{"web/a.ts", "README.md", "go.mod"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dominantScope(rules, tt.paths); got != tt.want {
+				t.Errorf("dominantScope(%q) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnforceScope(t *testing.T) {
+	rules := []ScopeRule{{Name: "api"}, {Name: "ui"}}
+	tests := []struct {
+		message string
+		scope   string
+		want    string
+	}{
+		{"feat(api): add users", "api", "feat(api): add users"},
+		{"feat(ui): add users", "api", "feat(ui): add users"},
+		{"feat(server): add users\n\nWhy.", "api", "feat(api): add users\n\nWhy."},
+		{"feat(server)!: drop v1", "", "feat!: drop v1"},
+		{"feat: add users", "api", "feat: add users"},
+		{"Add users", "api", "Add users"},
+	}
+	for _, tt := range tests {
+		if got := enforceScope(tt.message, rules, tt.scope); got != tt.want {
+			t.Errorf("enforceScope(%q, %q) = %q, want %q", tt.message, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestScopeInstruction(t *testing.T) {
+	rules := []ScopeRule{{Name: "api"}, {Name: "ui"}}
+	if got := scopeInstruction(rules, "ui"); got != "Use the scope `ui`. The only valid scopes are: api, ui." {
+		t.Errorf("scopeInstruction() = %q", got)
+	}
+	if got := scopeInstruction(rules, ""); got != "Do not use a scope. The only valid scopes are: api, ui, and this change doesn't belong to a single one." {
+		t.Errorf("scopeInstruction() without a scope = %q", got)
+	}
+}
+
+func TestMessagePromptAutoScope(t *testing.T) {
+	files := "M\tinternal/auth/login.go\nM\tinternal/auth/token.go"
+	cfg := &Config{Conventional: true, AutoScope: true}
+
+	prompt, scopes, scope := messagePrompt(cfg, "diff --git a/internal/auth/login.go b/internal/auth/login.go\n", files, false)
+	if scope != "auth" || len(scopes) != 1 || scopes[0].Name != "auth" {
+		t.Errorf("scopes = %+v and scope = %q, want auth", scopes, scope)
+	}
+	if !strings.Contains(prompt, "Use the scope `auth`.") {
+		t.Errorf("prompt lacks the scope:\n%s", prompt)
+	}
+
+	// Configured scopes win over the directories
+	cfg.Scopes = []ScopeRule{{Name: "login", Paths: []string{"internal/auth/login.go"}}}
+	if _, _, scope := messagePrompt(cfg, "", files, false); scope != "" {
+		t.Errorf("scope = %q with configured scopes, want none to dominate", scope)
+	}
+}
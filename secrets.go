@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maskedSecret replaces the high-entropy tokens in the diff.
+const maskedSecret = "[HIGH-ENTROPY-REDACTED]"
+
+// reSecretToken finds candidate tokens: runs of the characters keys and
+// tokens are made of, in base64, hex or URL-safe alphabets, long enough to
+// tell random from not. The = only pads the end, so in NAME=value the name
+// isn't part of the token.
+var reSecretToken = regexp.MustCompile(`[A-Za-z0-9+/_-]{20,}={0,2}`)
+
+// defaultEntropyAllowlist never masks commit hashes and other hex digests.
+var defaultEntropyAllowlist = []string{`^[0-9a-f]{7,64}$`}
+
+// maskSecrets replaces the tokens of the added lines whose Shannon entropy,
+// in bits per character, reaches the threshold, unless they match one of the
+// allowed patterns. It returns the diff and how many tokens were masked.
+func maskSecrets(diff string, threshold float64, allowlist []string) (string, int) {
+	allowed := make([]*regexp.Regexp, 0, len(allowlist))
+	for _, pattern := range allowlist {
+		if re, err := regexp.Compile(pattern); err == nil {
+			allowed = append(allowed, re)
+		}
+	}
+
+	masked := 0
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		lines[i] = reSecretToken.ReplaceAllStringFunc(line, func(token string) string {
+			if shannonEntropy(token) < threshold || matchesAny(allowed, token) {
+				return token
+			}
+			masked++
+			return maskedSecret
+		})
+	}
+
+	return strings.Join(lines, "\n"), masked
+}
+
+// shannonEntropy is the average information per character of the text, in
+// bits: about 4 for hex digests, 5 and more for random base64.
+func shannonEntropy(text string) float64 {
+	counts := map[rune]int{}
+	for _, r := range text {
+		counts[r]++
+	}
+
+	length := float64(utf8.RuneCountInString(text))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Skeleton is the fixed metadata composed around the generated message, so
+// it's always present no matter what the model returns.
+type Skeleton struct {
+	// TicketPattern finds the ticket in the branch name, e.g. `[A-Z]+-\d+`
+	TicketPattern string `json:"ticket_pattern"`
+
+	// TicketFormat renders the ticket in front of the subject, "[%s] " by
+	// default
+	TicketFormat string `json:"ticket_format"`
+
+	// Footers are appended as the last paragraph, e.g. "Reviewed-by: "
+	Footers []string `json:"footers"`
+}
+
+// compose lays out the ticket prefix, the generated subject and body, and
+// the fixed footers, in that order.
+func (s Skeleton) compose(message, branch string) string {
+	subject, body, _ := strings.Cut(message, "\n")
+	body = strings.TrimSpace(body)
+
+	if ticket := s.ticket(branch); ticket != "" && !strings.Contains(subject, ticket) {
+		format := s.TicketFormat
+		if format == "" {
+			format = "[%s] "
+		}
+		subject = fmt.Sprintf(format, ticket) + subject
+	}
+
+	parts := []string{subject}
+	if body != "" {
+		parts = append(parts, body)
+	}
+
+	footers := []string{}
+	for _, footer := range s.Footers {
+		if !strings.Contains(body, strings.TrimSpace(footer)) {
+			footers = append(footers, footer)
+		}
+	}
+	if len(footers) > 0 {
+		parts = append(parts, strings.Join(footers, "\n"))
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+func (s Skeleton) ticket(branch string) string {
+	if s.TicketPattern == "" {
+		return ""
+	}
+
+	re, err := regexp.Compile(s.TicketPattern)
+	if err != nil {
+		return ""
+	}
+
+	return re.FindString(branch)
+}
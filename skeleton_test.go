@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestSkeletonCompose(t *testing.T) {
+	skeleton := Skeleton{TicketPattern: `[A-Z]+-\d+`, Footers: []string{"Reviewed-by: Team <team@example.com>", "Refs: handbook"}}
+	tests := []struct {
+		name     string
+		skeleton Skeleton
+		message  string
+		branch   string
+		want     string
+	}{
+		{
+			"ticket, body and footers in order",
+			skeleton, "feat: add export\n\nUsers asked for CSV.", "feature/ABC-123-export",
+			"[ABC-123] feat: add export\n\nUsers asked for CSV.\n\nReviewed-by: Team <team@example.com>\nRefs: handbook",
+		},
+		{
+			"no body",
+			skeleton, "fix: typo", "ABC-7",
+			"[ABC-7] fix: typo\n\nReviewed-by: Team <team@example.com>\nRefs: handbook",
+		},
+		{
+			"no ticket in the branch",
+			skeleton, "fix: typo", "main",
+			"fix: typo\n\nReviewed-by: Team <team@example.com>\nRefs: handbook",
+		},
+		{
+			"ticket the model already wrote",
+			skeleton, "fix: typo in ABC-7", "ABC-7",
+			"fix: typo in ABC-7\n\nReviewed-by: Team <team@example.com>\nRefs: handbook",
+		},
+		{
+			"footer the model already wrote",
+			skeleton, "fix: typo\n\nRefs: handbook", "main",
+			"fix: typo\n\nRefs: handbook\n\nReviewed-by: Team <team@example.com>",
+		},
+		{
+			"ticket format",
+			Skeleton{TicketPattern: `#\d+`, TicketFormat: "%s: "}, "fix: typo", "fix/#42",
+			"#42: fix: typo",
+		},
+		{
+			"invalid pattern",
+			Skeleton{TicketPattern: `[`}, "fix: typo", "ABC-7",
+			"fix: typo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.skeleton.compose(tt.message, tt.branch); got != tt.want {
+				t.Errorf("compose() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinishMessageSkeleton(t *testing.T) {
+	isolate(t)
+	initRepo(t)
+	runGit(t, "checkout", "-q", "-b", "ABC-9-retry")
+	cfg := &Config{
+		Conventional:   true,
+		PostProcessors: defaultPostProcessors,
+		Skeleton:       &Skeleton{TicketPattern: `[A-Z]+-\d+`, Footers: []string{"Reviewed-by: Team <team@example.com>"}},
+		GeneratedBy:    true,
+	}
+
+	got := finishMessage(cfg, "```\nfix: retry uploads\n\n\n\nThey failed on flaky networks.\n```", nil, "", false, "m")
+	want := "[ABC-9] fix: retry uploads\n\nThey failed on flaky networks.\n\nReviewed-by: Team <team@example.com>\n" + generatedByTrailer("m")
+	if got != want {
+		t.Errorf("finishMessage() = %q, want %q", got, want)
+	}
+}
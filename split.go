@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// splitAreas is the number of top-level areas from which a change looks like
+// several commits in one.
+const splitAreas = 3
+
+// changeArea is the top-level directory of a path, "." for files at the root.
+func changeArea(path string) string {
+	area, _, found := strings.Cut(path, "/")
+	if !found {
+		return "."
+	}
+
+	return area
+}
+
+// clusterFiles groups `git diff --name-status` lines by the area of their
+// path, keeping the lines as they are.
+func clusterFiles(files string) map[string][]string {
+	clusters := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(files), "\n") {
+		paths := changedPaths(line)
+		if len(paths) == 0 {
+			continue
+		}
+		area := changeArea(paths[0])
+		clusters[area] = append(clusters[area], line)
+	}
+
+	return clusters
+}
+
+// diffForArea keeps only the files of the diff within the area.
+func diffForArea(diff, area string) string {
+	var kept []string
+	keep := false
+	for _, line := range strings.Split(diff, "\n") {
+		if header, ok := strings.CutPrefix(line, "diff --git "); ok {
+			keep = changeArea(diffHeaderPath(header)) == area
+		}
+		if keep {
+			kept = append(kept, line)
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+// diffHeaderPath is the new path of a file from its `diff --git` header.
+func diffHeaderPath(header string) string {
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+len(" b/"):]
+	}
+
+	return header
+}
+
+// suggestSplit warns when the change spans several unrelated areas and, when
+// running interactively, offers a message for each area to commit separately.
+func suggestSplit(cfg *Config, diff, files, apiKey string) {
+	clusters := clusterFiles(files)
+	if len(clusters) < splitAreas {
+		return
+	}
+
+	areas := make([]string, 0, len(clusters))
+	for area := range clusters {
+		areas = append(areas, area)
+	}
+	sort.Strings(areas)
+
+	fmt.Fprintf(os.Stderr, "⚠️ The staged changes touch %d unrelated areas (%s), consider splitting the commit\n", len(areas), strings.Join(areas, ", "))
+	if !cfg.Interactive || !confirm("Suggest a message for each area?") {
+		return
+	}
+
+	for _, area := range areas {
+		message := generateCommitMessage(cfg, diffForArea(diff, area), strings.Join(clusters[area], "\n"), apiKey)
+		if message == "" {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "💡 %s:\n%s\n\n", area, message)
+	}
+}
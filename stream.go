@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OpenAIStreamChunk is a single server-sent event of a streamed completion.
+type OpenAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// streamFirstLine streams the completion and stops reading as soon as the
+// first non-empty line is complete, so the rest is never waited for. It also
+// reports whether the API answered, see completeAnswered.
+func streamFirstLine(cfg *Config, requestData OpenAIRequest, apiKey string) (string, bool) {
+	req, err := newChatRequest(cfg, requestData, apiKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+		return "", false
+	}
+
+	waitForRequest(cfg)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		debugLog(cfg, apiKey, req, 0, []byte(err.Error()))
+		fmt.Fprintf(os.Stderr, "❌ Error sending request: %s\n", err)
+		return "", false
+	}
+	// Closing the body early cancels the rest of the stream
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		debugLog(cfg, apiKey, req, resp.StatusCode, body)
+		fmt.Fprintf(os.Stderr, "❌ API error (status %d): %s\n", resp.StatusCode, body)
+		return "", false
+	}
+
+	// Log whatever part of the stream was read
+	var raw strings.Builder
+	defer func() {
+		debugLog(cfg, apiKey, req, resp.StatusCode, []byte(raw.String()))
+	}()
+
+	var content strings.Builder
+	var finishReason string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		raw.WriteString(scanner.Text() + "\n")
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error parsing response: %s\n", err)
+			return "", false
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+
+		// Fences and blank lines may precede the subject, wait for a real line
+		text := stripMarkdownFences(stripReasoning(content.String(), cfg.ReasoningPatterns))
+		if line, _, found := strings.Cut(text, "\n"); found && strings.TrimSpace(line) != "" {
+			return text, true
+		}
+	}
+
+	if strings.TrimSpace(content.String()) == "" {
+		reportEmptyCompletion(finishReason)
+	}
+
+	return trimTruncated(content.String(), finishReason), true
+}
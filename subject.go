@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// graphemes splits the text into what reads as single characters: a base
+// with its combining marks, an emoji with its variation selector, skin tone
+// and zero-width-joined parts, or a pair of regional indicators forming a
+// flag. It covers what commit subjects contain, not every rule of UAX #29.
+func graphemes(text string) []string {
+	var clusters []string
+	joined := false
+	indicators := 0
+	for _, r := range text {
+		extends := unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+			(r >= '\ufe00' && r <= '\ufe0f') ||
+			(r >= 0x1f3fb && r <= 0x1f3ff) ||
+			r == zeroWidthJoiner
+
+		indicator := r >= 0x1f1e6 && r <= 0x1f1ff
+		if indicator {
+			indicators++
+		} else if !extends {
+			indicators = 0
+		}
+		// The second indicator of a pair completes the flag
+		pairs := indicator && indicators%2 == 0
+
+		if len(clusters) > 0 && (extends || joined || pairs) {
+			clusters[len(clusters)-1] += string(r)
+		} else {
+			clusters = append(clusters, string(r))
+		}
+		joined = r == zeroWidthJoiner
+	}
+
+	return clusters
+}
+
+// graphemeLength counts the characters of the text as a reader would, so
+// emoji and accented or CJK characters each count once.
+func graphemeLength(text string) int {
+	return len(graphemes(text))
+}
+
+// shortenSubject cuts a subject longer than max characters at the last word
+// that fits, or mid-word when even the first word is too long. The body is
+// kept as it is.
+func shortenSubject(message string, max int) string {
+	subject, body, hasBody := strings.Cut(message, "\n")
+	clusters := graphemes(subject)
+	if max <= 0 || len(clusters) <= max {
+		return message
+	}
+
+	shortened := strings.Join(clusters[:max], "")
+	// Cut where the next word would have started, if that still leaves one
+	if clusters[max] != " " {
+		if i := strings.LastIndex(shortened, " "); i > 0 {
+			shortened = shortened[:i]
+		}
+	}
+	shortened = strings.TrimRight(shortened, " ,;:-")
+	fmt.Fprintf(os.Stderr, "✂️ Shortened the subject from %d to %d characters\n", len(clusters), graphemeLength(shortened))
+
+	if !hasBody {
+		return shortened
+	}
+	return shortened + "\n" + body
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraphemeLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"fix: typo", 9},
+		{"naprawa błędu", 13},
+		{"café", 4},
+		{"cafe\u0301", 4},
+		{"修复错误", 4},
+		{"✨ feat", 6},
+		{"❤️", 1},
+		{"👍🏽", 1},
+		{"👩‍💻 code", 6},
+		{"👨‍👩‍👧‍👦", 1},
+		{"🇵🇱🇩🇪", 2},
+		{"🇵🇱 flag", 6},
+	}
+	for _, tt := range tests {
+		if got := graphemeLength(tt.text); got != tt.want {
+			t.Errorf("graphemeLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestShortenSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		max     int
+		want    string
+	}{
+		{"fits", "fix: typo", 9, "fix: typo"},
+		{"off", "fix: a much longer subject", 0, "fix: a much longer subject"},
+		{"at a word", "fix: a much longer subject", 12, "fix: a much"},
+		{"at a space", "fix: a much longer subject", 11, "fix: a much"},
+		{"mid-word", "refactor:everything", 8, "refactor"},
+		{"trailing punctuation", "fix: parser, lexer", 14, "fix: parser"},
+		{"body kept", "fix: a much longer subject\n\nWhy.", 12, "fix: a much\n\nWhy."},
+		{"gitmoji fits", "✨ feat: add the parser", 22, "✨ feat: add the parser"},
+		{"gitmoji", "✨ feat: add the parser", 16, "✨ feat: add the"},
+		{"polish fits", "naprawa błędu w łączeniu", 24, "naprawa błędu w łączeniu"},
+		{"polish", "naprawa błędu w łączeniu", 15, "naprawa błędu w"},
+		{"cjk fits", "修复 登录 错误", 8, "修复 登录 错误"},
+		{"cjk", "修复 登录 错误", 6, "修复 登录"},
+		{"cjk without spaces", "修复登录错误问题", 4, "修复登录"},
+		{"combining mark", "fix: cafe\u0301 menu", 9, "fix: cafe\u0301"},
+		{"joined emoji mid-word", "👩‍💻👩‍💻👩‍💻", 2, "👩‍💻👩‍💻"},
+		{"flags", "🇵🇱🇩🇪🇫🇷", 2, "🇵🇱🇩🇪"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			captureStderr(t, func() { got = shortenSubject(tt.message, tt.max) })
+			if got != tt.want {
+				t.Errorf("shortenSubject() = %q, want %q", got, tt.want)
+			}
+			subject, _, _ := strings.Cut(got, "\n")
+			if tt.max > 0 && graphemeLength(subject) > tt.max {
+				t.Errorf("subject %q is %d characters, more than %d", subject, graphemeLength(subject), tt.max)
+			}
+		})
+	}
+}
+
+func TestShortenSubjectReportsCharacters(t *testing.T) {
+	stderr := captureStderr(t, func() { shortenSubject("✨ naprawa błędu w łączeniu", 17) })
+	if !strings.Contains(stderr, "from 26 to 17 characters") {
+		t.Errorf("stderr = %q, want the lengths in characters", stderr)
+	}
+}
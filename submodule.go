@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// maxSubmoduleCommits caps the commit subjects listed for a submodule bump.
+const maxSubmoduleCommits = 20
+
+// describeSubmodules replaces the opaque `Subproject commit` lines of
+// submodule changes with a sentence saying what happened, and with log the
+// subjects of the submodule commits in between. With anonymize the sentence
+// names the submodule by its pseudonym and the subjects, which could tell
+// the project, are left out.
+func describeSubmodules(diff string, log, anonymize bool) string {
+	var result, section []string
+	flush := func() {
+		result = append(result, describeSubmoduleChange(section, log, anonymize)...)
+		section = nil
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+		}
+		section = append(section, line)
+	}
+	flush()
+
+	return strings.Join(result, "\n")
+}
+
+// describeSubmoduleChange returns the lines of a single file's diff,
+// described in words when the file is a submodule.
+func describeSubmoduleChange(lines []string, log, anonymize bool) []string {
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "diff --git ") {
+		return lines
+	}
+
+	path := strings.TrimPrefix(lines[0], "diff --git a/")
+	if i := strings.LastIndex(path, " b/"); i >= 0 {
+		path = path[i+len(" b/"):]
+	}
+	var from, to string
+	for _, line := range lines[1:] {
+		if commit, ok := strings.CutPrefix(line, "-Subproject commit "); ok {
+			from = strings.TrimSuffix(commit, "-dirty")
+		}
+		if commit, ok := strings.CutPrefix(line, "+Subproject commit "); ok {
+			to = strings.TrimSuffix(commit, "-dirty")
+		}
+	}
+
+	name := path
+	if anonymize {
+		name = pathPseudonym(path)
+	}
+
+	var description []string
+	switch {
+	case from != "" && to != "":
+		description = []string{fmt.Sprintf("bumped submodule %s from %s to %s", name, shortCommit(from), shortCommit(to))}
+		if log && !anonymize {
+			for _, subject := range submoduleLog(path, from, to) {
+				description = append(description, "  - "+subject)
+			}
+		}
+	case to != "":
+		description = []string{fmt.Sprintf("added submodule %s at %s", name, shortCommit(to))}
+	case from != "":
+		description = []string{fmt.Sprintf("removed submodule %s, which was at %s", name, shortCommit(from))}
+	default:
+		return lines
+	}
+
+	described := append([]string{lines[0]}, description...)
+	// Keep the trailing empty line separating this file from the next
+	if lines[len(lines)-1] == "" {
+		described = append(described, "")
+	}
+
+	return described
+}
+
+// submoduleLog lists the subjects of the submodule's commits between the two,
+// newest first. It's empty when the submodule isn't checked out or lacks them.
+func submoduleLog(path, from, to string) []string {
+	dir := filepath.Join(getRepoRoot(), path)
+	cmd := exec.Command("git", "-C", dir, "log", "--format=%s", "-n", fmt.Sprint(maxSubmoduleCommits), from+".."+to)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	subjects := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if subjects[0] == "" {
+		return nil
+	}
+
+	return subjects
+}
+
+// shortCommit abbreviates a commit hash the way git usually shows it.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+
+	return commit
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDescribeSubmoduleChanges(t *testing.T) {
+	header := "diff --git a/libs/foo b/libs/foo\n"
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{
+			"added",
+			header + "new file mode 160000\nindex 0000000..2222222\n--- /dev/null\n+++ b/libs/foo\n@@ -0,0 +1 @@\n+Subproject commit 2222222222222222222222222222222222222222\n",
+			header + "added submodule libs/foo at 2222222\n",
+		},
+		{
+			"removed",
+			header + "deleted file mode 160000\nindex 1111111..0000000\n--- a/libs/foo\n+++ /dev/null\n@@ -1 +0,0 @@\n-Subproject commit 1111111111111111111111111111111111111111\n",
+			header + "removed submodule libs/foo, which was at 1111111\n",
+		},
+		{
+			"dirty",
+			header + "index 1111111..2222222 160000\n--- a/libs/foo\n+++ b/libs/foo\n@@ -1 +1 @@\n-Subproject commit 1111111111111111111111111111111111111111\n+Subproject commit 2222222222222222222222222222222222222222-dirty\n",
+			header + "bumped submodule libs/foo from 1111111 to 2222222\n",
+		},
+		{
+			"regular file untouched",
+			"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-package a\n+package b\n",
+			"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-package a\n+package b\n",
+		},
+		{
+			"among other files",
+			"diff --git a/main.go b/main.go\n+x\n" + header + "+Subproject commit 2222222222222222222222222222222222222222\ndiff --git a/z.go b/z.go\n+y\n",
+			"diff --git a/main.go b/main.go\n+x\n" + header + "added submodule libs/foo at 2222222\ndiff --git a/z.go b/z.go\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeSubmodules(tt.diff, true, false); got != tt.want {
+				t.Errorf("describeSubmodules() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmoduleBump(t *testing.T) {
+	isolate(t)
+	runGit(t, "config", "--global", "protocol.file.allow", "always")
+	lib := initRepo(t)
+	commitFile(t, "foo.go", "package foo\n", "feat: add foo")
+	commitFile(t, "foo.go", "package foo\n\nfunc A() {}\n", "feat: add A")
+	commitFile(t, "foo.go", "package foo\n\nfunc A() {}\n\nfunc B() {}\n", "feat: add B")
+	from := strings.TrimSpace(runGit(t, "rev-parse", "HEAD~2"))
+	to := strings.TrimSpace(runGit(t, "rev-parse", "HEAD"))
+
+	initRepo(t)
+	runGit(t, "submodule", "add", "-q", lib, "libs/foo")
+	runGit(t, "-C", filepath.Join("libs", "foo"), "checkout", "-q", from)
+	runGit(t, "add", "libs/foo")
+	runGit(t, "commit", "-q", "-m", "chore: add libfoo")
+	runGit(t, "-C", filepath.Join("libs", "foo"), "checkout", "-q", "main")
+	runGit(t, "add", "libs/foo")
+
+	cfg := &Config{DiffTarget: diffTargetStaged}
+	diff, files, err := collectChanges(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "bumped submodule libs/foo from " + from[:7] + " to " + to[:7]
+
+	cfg.SubmoduleLog = true
+	prompt := buildUserPrompt(cfg, diff, files)
+	if strings.Contains(prompt, "Subproject commit") {
+		t.Errorf("prompt has the opaque lines:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, want+"\n  - feat: add B\n  - feat: add A\n") {
+		t.Errorf("prompt lacks the bump with its commits:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "add foo") {
+		t.Errorf("prompt lists the commit it bumped from:\n%s", prompt)
+	}
+
+	cfg.SubmoduleLog = false
+	if prompt := buildUserPrompt(cfg, diff, files); !strings.Contains(prompt, want) || strings.Contains(prompt, "feat: add") {
+		t.Errorf("prompt without the submodule log:\n%s", prompt)
+	}
+}
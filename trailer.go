@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// generatedByTrailer records which tool and model produced the message, in
+// a form git interpret-trailers understands.
+func generatedByTrailer(model string) string {
+	return fmt.Sprintf("Generated-by: %s model=%s", userAgent(), model)
+}
+
+// appendTrailer adds the trailer to the message's trailer block, starting
+// one after a blank line when the message doesn't end with trailers yet.
+func appendTrailer(message, trailer string) string {
+	if message == "" {
+		return message
+	}
+
+	paragraphs := strings.Split(message, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	if len(paragraphs) > 1 && isTrailerBlock(last) {
+		return message + "\n" + trailer
+	}
+
+	return message + "\n\n" + trailer
+}
+
+// isTrailerBlock reports whether every line of the paragraph is a trailer.
+func isTrailerBlock(paragraph string) bool {
+	for _, line := range strings.Split(paragraph, "\n") {
+		if !reTrailer.MatchString(line) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,23 @@
+package main
+
+import "runtime/debug"
+
+// version is set at build time with -ldflags "-X main.version=...", falling
+// back to the module version for `go install` builds.
+var version = ""
+
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	return "dev"
+}
+
+// userAgent identifies the tool on outbound API requests.
+func userAgent() string {
+	return "commitment/" + currentVersion()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+const defaultWrapWidth = 72
+
+var (
+	reListItem = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s`)
+	reTrailer  = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|BREAKING CHANGE)(: | #)\S`)
+)
+
+// wrapBody reflows the paragraphs of the message body to the given width.
+// The subject, code blocks, indented blocks, bullet lists and trailers are
+// left as they are.
+func wrapBody(message string, width int) string {
+	subject, body, hasBody := strings.Cut(message, "\n")
+	if !hasBody || width <= 0 {
+		return message
+	}
+
+	var out []string
+	var paragraph []string
+	inCode := false
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out = append(out, reflowParagraph(paragraph, width)...)
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			flush()
+			inCode = !inCode
+			out = append(out, line)
+			continue
+		}
+
+		if inCode || strings.TrimSpace(line) == "" {
+			flush()
+			out = append(out, line)
+			continue
+		}
+
+		paragraph = append(paragraph, line)
+	}
+	flush()
+
+	return subject + "\n" + strings.Join(out, "\n")
+}
+
+// reflowParagraph joins the lines of a prose paragraph and breaks them again
+// at word boundaries. Paragraphs with any structure are returned unchanged.
+func reflowParagraph(lines []string, width int) []string {
+	trailers := true
+	for _, line := range lines {
+		if reListItem.MatchString(line) || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			return lines
+		}
+		trailers = trailers && reTrailer.MatchString(line)
+	}
+	if trailers {
+		return lines
+	}
+
+	words := strings.Fields(strings.Join(lines, " "))
+	wrapped := []string{}
+	current := ""
+	for _, word := range words {
+		if current != "" && graphemeLength(current)+1+graphemeLength(word) > width {
+			wrapped = append(wrapped, current)
+			current = ""
+		}
+
+		if current == "" {
+			current = word
+		} else {
+			current += " " + word
+		}
+	}
+	if current != "" {
+		wrapped = append(wrapped, current)
+	}
+
+	return wrapped
+}